@@ -0,0 +1,250 @@
+// Command testenv keeps the backing services of the tests running between go test invocations.
+//
+//	eval "$(testenv up)"   # start postgres, kafka and redis, export their addresses
+//	eval "$(testenv env)"  # export the addresses of the running services
+//	testenv down           # remove the services
+//
+// Commands take the service names to select them, default is all.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/client"
+
+	"github.com/worldline-go/test/container/containerkafka"
+	"github.com/worldline-go/test/container/containerpostgres"
+	"github.com/worldline-go/test/container/containerredis"
+	"github.com/worldline-go/test/utils"
+)
+
+type service struct {
+	name  string
+	start func(ctx context.Context) (map[string]string, error)
+}
+
+var services = []service{
+	{name: containerpostgres.EnvName, start: containerpostgres.StartEnv},
+	{name: containerkafka.EnvName, start: containerkafka.StartEnv},
+	{name: containerredis.EnvName, start: containerredis.StartEnv},
+}
+
+const usage = `usage: testenv up|down|env [postgres|kafka|redis]...
+
+  up    start the services or attach to the running ones, print the export lines
+  down  remove the services
+  env   print the export lines of the running services
+`
+
+func main() {
+	// containers must outlive this process
+	os.Setenv("TESTCONTAINERS_RYUK_DISABLED", "true")
+
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	selected, err := selectServices(os.Args[2:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	switch os.Args[1] {
+	case "up":
+		err = up(ctx, selected)
+	case "down":
+		err = down(ctx, selected)
+	case "env":
+		err = env(ctx, selected)
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func selectServices(names []string) ([]service, error) {
+	if len(names) == 0 {
+		return services, nil
+	}
+
+	var result []service
+	for _, name := range names {
+		i := slices.IndexFunc(services, func(s service) bool { return s.name == "testenv-"+name })
+		if i < 0 {
+			return nil, fmt.Errorf("unknown service %q", name)
+		}
+
+		result = append(result, services[i])
+	}
+
+	return result, nil
+}
+
+// up starts the services together, Kafka takes the longest.
+func up(ctx context.Context, selected []service) error {
+	// testcontainers panics without docker
+	cli, err := dockerClient(ctx)
+	if err != nil {
+		return err
+	}
+	cli.Close()
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		envs = map[string]string{}
+		errs []error
+	)
+
+	for _, s := range selected {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			fmt.Fprintf(os.Stderr, "starting %s\n", s.name)
+
+			v, err := s.start(ctx)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", s.name, err))
+
+				return
+			}
+
+			maps.Copy(envs, v)
+		}()
+	}
+
+	wg.Wait()
+
+	printExports(envs)
+
+	return errors.Join(errs...)
+}
+
+// env prints the export lines of the running services, it doesn't start the stopped ones.
+func env(ctx context.Context, selected []service) error {
+	running, err := listContainers(ctx, false)
+	if err != nil {
+		return err
+	}
+
+	envs := map[string]string{}
+	for _, s := range selected {
+		if !slices.ContainsFunc(running, func(c container.Summary) bool { return slices.Contains(c.Names, "/"+s.name) }) {
+			return fmt.Errorf("%s is not running, start it with testenv up", s.name)
+		}
+
+		// attaches to the running container
+		v, err := s.start(ctx)
+		if err != nil {
+			return fmt.Errorf("%s: %w", s.name, err)
+		}
+
+		maps.Copy(envs, v)
+	}
+
+	printExports(envs)
+
+	return nil
+}
+
+// down removes the service containers with their volumes.
+func down(ctx context.Context, selected []service) error {
+	cli, err := dockerClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer cli.Close()
+
+	containers, err := listContainers(ctx, true)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, s := range selected {
+		for _, c := range containers {
+			if !slices.Contains(c.Names, "/"+s.name) {
+				continue
+			}
+
+			fmt.Fprintf(os.Stderr, "removing %s\n", s.name)
+
+			if err := cli.ContainerRemove(ctx, c.ID, container.RemoveOptions{Force: true, RemoveVolumes: true}); err != nil {
+				errs = append(errs, fmt.Errorf("could not remove %s: %w", s.name, err))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// listContainers returns the containers of testenv, all includes the stopped ones.
+func listContainers(ctx context.Context, all bool) ([]container.Summary, error) {
+	cli, err := dockerClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer cli.Close()
+
+	containers, err := cli.ContainerList(ctx, container.ListOptions{
+		All:     all,
+		Filters: filters.NewArgs(filters.Arg("label", utils.LabelEnv)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not list containers: %w", err)
+	}
+
+	return containers, nil
+}
+
+func dockerClient(ctx context.Context) (*client.Client, error) {
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to docker: %w", err)
+	}
+
+	if _, err := cli.Ping(ctx); err != nil {
+		cli.Close()
+
+		return nil, fmt.Errorf("could not connect to docker: %w", err)
+	}
+
+	return cli, nil
+}
+
+func printExports(envs map[string]string) {
+	for _, key := range slices.Sorted(maps.Keys(envs)) {
+		fmt.Printf("export %s=%s\n", key, shellQuote(envs[key]))
+	}
+}
+
+func shellQuote(v string) string {
+	return "'" + strings.ReplaceAll(v, "'", `'\''`) + "'"
+}
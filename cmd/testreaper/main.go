@@ -0,0 +1,72 @@
+// Command testreaper removes the containers, networks and volumes of the test library left by killed test runs.
+//
+//	testreaper -older-than 2h -label ci.project.id=42 -dry-run
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/worldline-go/test/utils"
+)
+
+type labelsFlag []string
+
+func (l *labelsFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *labelsFlag) Set(v string) error {
+	*l = append(*l, v)
+
+	return nil
+}
+
+func main() {
+	var opts utils.ReapOptions
+
+	flag.Var((*labelsFlag)(&opts.Labels), "label", `label selector "key" or "key=value", can be repeated`)
+	flag.DurationVar(&opts.OlderThan, "older-than", time.Hour, "remove only resources older than this, 0 for all")
+	flag.BoolVar(&opts.DryRun, "dry-run", false, "list the resources without removing them")
+	session := flag.String("session", "", "select the resources of a test session, shortcut of -label "+utils.LabelSession+"=<id>")
+	flag.Parse()
+
+	if *session != "" {
+		opts.Labels = append(opts.Labels, utils.LabelSession+"="+*session)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	reaped, err := utils.Reap(ctx, opts)
+	if reaped != nil {
+		action := "removed"
+		if opts.DryRun {
+			action = "would remove"
+		}
+
+		printResources(action, "container", reaped.Containers)
+		printResources(action, "network", reaped.Networks)
+		printResources(action, "volume", reaped.Volumes)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func printResources(action, kind string, resources []utils.Resource) {
+	for _, r := range resources {
+		fmt.Printf("%s %s %s service=%s session=%s age=%s\n",
+			action, kind, r.Name,
+			r.Labels[utils.LabelService], r.Labels[utils.LabelSession],
+			time.Since(r.Created).Truncate(time.Second),
+		)
+	}
+}
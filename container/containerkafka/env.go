@@ -0,0 +1,38 @@
+package containerkafka
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/testcontainers/testcontainers-go"
+
+	"github.com/worldline-go/test/utils"
+)
+
+// EnvName is the container name of the testenv command.
+const EnvName = "testenv-kafka"
+
+// StartEnv starts the Kafka container of the local dev environment, or attaches to the running one.
+//   - Container is kept running, disable the testcontainers reaper to keep it after the process exits.
+//   - Returns the KAFKA_BROKER env picked up by New.
+func StartEnv(ctx context.Context) (map[string]string, error) {
+	req := brokerRequest(kafkaImage(), 0, "kafka", nil)
+	req.Name = EnvName
+	req.Labels[utils.LabelEnv] = "true"
+
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+		Reuse:            true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not create Kafka container: %w", err)
+	}
+
+	address, err := utils.MappedAddress(ctx, container, "9092/tcp")
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]string{"KAFKA_BROKER": address}, nil
+}
@@ -0,0 +1,21 @@
+package containerkafka
+
+import (
+	"fmt"
+
+	"github.com/twmb/franz-go/pkg/kfake"
+)
+
+// newFake starts an in-process kfake cluster listening on random local ports.
+//   - Topics are created on first use like the container default.
+func newFake(brokers int) (*kfake.Cluster, error) {
+	cluster, err := kfake.NewCluster(
+		kfake.NumBrokers(brokers),
+		kfake.AllowAutoTopicCreation(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("could not start kfake cluster: %w", err)
+	}
+
+	return cluster, nil
+}
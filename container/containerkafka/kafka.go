@@ -1,15 +1,21 @@
 package containerkafka
 
 import (
-	"net"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
 	"os"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
 
-	"github.com/docker/docker/api/types/container"
-	"github.com/docker/go-connections/nat"
+	"github.com/google/uuid"
 	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/network"
 	"github.com/testcontainers/testcontainers-go/wait"
+	"github.com/twmb/franz-go/pkg/kfake"
 
 	"github.com/worldline-go/test/utils"
 	"github.com/worldline-go/test/utils/kafkautils"
@@ -19,10 +25,14 @@ import (
 var DefaultKafkaImage = "docker.io/bitnamilegacy/kafka:3.8.1"
 
 type Container struct {
-	container testcontainers.Container
+	containers []testcontainers.Container
+	network    *testcontainers.DockerNetwork
+	fake       *kfake.Cluster
 	*kafkautils.KafkaTest
 
 	address []string
+	// shared containers are used by other packages, not terminated in Stop
+	shared bool
 }
 
 func (p *Container) Stop(t *testing.T) {
@@ -32,84 +42,224 @@ func (p *Container) Stop(t *testing.T) {
 		p.KafkaTest.Client.Close()
 	}
 
-	if p.container != nil {
-		if err := p.container.Terminate(t.Context()); err != nil {
-			t.Fatalf("could not stop Kafka container: %v", err)
+	if !p.shared {
+		for _, container := range p.containers {
+			if err := container.Terminate(t.Context()); err != nil {
+				t.Fatalf("could not stop Kafka container: %v", err)
+			}
+		}
+	}
+
+	if p.fake != nil {
+		p.fake.Close()
+	}
+
+	if p.network != nil {
+		if err := p.network.Remove(t.Context()); err != nil {
+			t.Fatalf("could not remove Kafka network: %v", err)
 		}
 	}
 }
 
-func New(t *testing.T) *Container {
+func New(t *testing.T, opts ...Option) *Container {
 	t.Helper()
 
-	var kafkaContainer testcontainers.Container
+	o := option{}
+	o.apply(opts...)
+
+	result := &Container{}
 
-	var addr []string
 	if v := os.Getenv("KAFKA_BROKER"); v != "" {
-		addr = strings.Fields(strings.ReplaceAll(v, ",", " "))
+		result.address = strings.Fields(strings.ReplaceAll(v, ",", " "))
 	}
 
-	if len(addr) == 0 {
-		image := DefaultKafkaImage
-		if v := os.Getenv("TEST_IMAGE_KAFKA"); v != "" {
-			image = v
-		}
-
-		announceIP := utils.DockerHost()
-
-		container, err := testcontainers.GenericContainer(t.Context(), testcontainers.GenericContainerRequest{
-			ContainerRequest: testcontainers.ContainerRequest{
-				Image: image,
-				Env: map[string]string{
-					"ALLOW_PLAINTEXT_LISTENER":                 "yes",
-					"KAFKA_CFG_NODE_ID":                        "0",
-					"KAFKA_CFG_PROCESS_ROLES":                  "controller,broker",
-					"KAFKA_CFG_CONTROLLER_QUORUM_VOTERS":       "0@:9093",
-					"KAFKA_CFG_LISTENERS":                      "PLAINTEXT://:9092,CONTROLLER://:9093,INTERNAL://:9094",
-					"KAFKA_CFG_ADVERTISED_LISTENERS":           "PLAINTEXT://" + announceIP + ":9092,INTERNAL://kafka:9094",
-					"KAFKA_CFG_LISTENER_SECURITY_PROTOCOL_MAP": "CONTROLLER:PLAINTEXT,PLAINTEXT:PLAINTEXT,INTERNAL:PLAINTEXT",
-					"KAFKA_CFG_CONTROLLER_LISTENER_NAMES":      "CONTROLLER",
-				},
-				WaitingFor:   wait.ForLog("Kafka Server started"),
-				ExposedPorts: []string{"9092/tcp"},
-				HostConfigModifier: func(hostConfig *container.HostConfig) {
-					hostConfig.PortBindings = nat.PortMap{
-						"9092/tcp": []nat.PortBinding{
-							{
-								HostIP:   "0.0.0.0",
-								HostPort: "9092",
-							},
-						},
-					}
-				},
-				Labels: utils.EnvToLabels(),
-			},
-			Started:      true,
-			ProviderType: 0,
-			Reuse:        false,
-		})
+	if len(result.address) == 0 && (o.Fake || os.Getenv("KAFKA_BACKEND") == "kfake") {
+		cluster, err := newFake(o.Brokers)
 		if err != nil {
-			t.Fatalf("could not create Kafka container: %v", err)
+			t.Fatal(err)
 		}
 
-		host, err := container.Host(t.Context())
-		if err != nil {
-			t.Fatalf("could not get host: %v", err)
+		result.fake = cluster
+		result.address = cluster.ListenAddrs()
+	}
+
+	if len(result.address) == 0 {
+		image := kafkaImage()
+
+		if o.Brokers == 1 {
+			// clusters are not shared, their network is not reusable
+			result.shared = utils.Shared()
+
+			req := brokerRequest(image, 0, "kafka", nil)
+			if result.shared {
+				req.Name = utils.SharedName("kafka", image, o.Brokers)
+			}
+
+			container, err := testcontainers.GenericContainer(t.Context(), testcontainers.GenericContainerRequest{
+				ContainerRequest: req,
+				Started:          true,
+				ProviderType:     0,
+				Reuse:            result.shared,
+			})
+			if err != nil {
+				t.Fatalf("could not create Kafka container: %v", err)
+			}
+
+			result.containers = append(result.containers, container)
+		} else {
+			if err := result.startCluster(t, image, o.Brokers); err != nil {
+				result.Stop(t)
+				t.Fatal(err)
+			}
 		}
 
-		addr = []string{net.JoinHostPort(host, "9092")}
-		kafkaContainer = container
+		for _, container := range result.containers {
+			address, err := utils.MappedAddress(t.Context(), container, "9092/tcp")
+			if err != nil {
+				result.Stop(t)
+				t.Fatal(err)
+			}
+
+			result.address = append(result.address, address)
+		}
 	}
 
-	kafka := kafkautils.NewTest(t, wkafka.Config{Brokers: addr})
+	result.KafkaTest = kafkautils.NewTest(t, wkafka.Config{Brokers: result.address})
+
+	return result
+}
 
-	return &Container{
-		container: kafkaContainer,
-		address:   addr,
-		KafkaTest: kafka,
+// kafkaImage returns the TEST_IMAGE_KAFKA env, default is DefaultKafkaImage.
+func kafkaImage() string {
+	if v := os.Getenv("TEST_IMAGE_KAFKA"); v != "" {
+		return v
 	}
+
+	return DefaultKafkaImage
 }
 
 func (p *Container) Address() []string {
 	return p.address
 }
+
+// startCluster starts the KRaft cluster nodes on a shared network.
+//   - Nodes are started together, the controller quorum needs all voters.
+func (p *Container) startCluster(t *testing.T, image string, brokers int) error {
+	t.Helper()
+
+	nw, err := network.New(t.Context(), network.WithLabels(utils.Labels("kafka")))
+	if err != nil {
+		return fmt.Errorf("could not create Kafka network: %w", err)
+	}
+
+	p.network = nw
+
+	clusterID := uuid.New()
+	voters := make([]string, 0, brokers)
+	for i := range brokers {
+		voters = append(voters, strconv.Itoa(i)+"@"+brokerAlias(i)+":9093")
+	}
+
+	replication := strconv.Itoa(min(brokers, 3))
+	env := map[string]string{
+		"KAFKA_KRAFT_CLUSTER_ID":                             base64.RawURLEncoding.EncodeToString(clusterID[:]),
+		"KAFKA_CFG_CONTROLLER_QUORUM_VOTERS":                 strings.Join(voters, ","),
+		"KAFKA_CFG_INTER_BROKER_LISTENER_NAME":               "INTERNAL",
+		"KAFKA_CFG_OFFSETS_TOPIC_REPLICATION_FACTOR":         replication,
+		"KAFKA_CFG_TRANSACTION_STATE_LOG_REPLICATION_FACTOR": replication,
+		"KAFKA_CFG_TRANSACTION_STATE_LOG_MIN_ISR":            "1",
+	}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+
+	containers := make([]testcontainers.Container, brokers)
+	for i := range brokers {
+		req := brokerRequest(image, i, brokerAlias(i), env)
+		req.Networks = []string{nw.Name}
+		req.NetworkAliases = map[string][]string{nw.Name: {brokerAlias(i)}}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			container, err := testcontainers.GenericContainer(t.Context(), testcontainers.GenericContainerRequest{
+				ContainerRequest: req,
+				Started:          true,
+			})
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if container != nil {
+				containers[i] = container
+			}
+
+			if err != nil {
+				errs = append(errs, fmt.Errorf("could not create Kafka broker %d: %w", i, err))
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	for _, container := range containers {
+		if container != nil {
+			p.containers = append(p.containers, container)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// brokerRequest returns the container request of a combined controller and broker node.
+//   - alias is the internal host name of the node.
+func brokerRequest(image string, nodeID int, alias string, env map[string]string) testcontainers.ContainerRequest {
+	brokerEnv := map[string]string{
+		"ALLOW_PLAINTEXT_LISTENER":                 "yes",
+		"KAFKA_CFG_NODE_ID":                        strconv.Itoa(nodeID),
+		"KAFKA_CFG_PROCESS_ROLES":                  "controller,broker",
+		"KAFKA_CFG_CONTROLLER_QUORUM_VOTERS":       "0@:9093",
+		"KAFKA_CFG_LISTENERS":                      "PLAINTEXT://:9092,CONTROLLER://:9093,INTERNAL://:9094",
+		"KAFKA_CFG_LISTENER_SECURITY_PROTOCOL_MAP": "CONTROLLER:PLAINTEXT,PLAINTEXT:PLAINTEXT,INTERNAL:PLAINTEXT",
+		"KAFKA_CFG_CONTROLLER_LISTENER_NAMES":      "CONTROLLER",
+	}
+
+	for k, v := range env {
+		brokerEnv[k] = v
+	}
+
+	return testcontainers.ContainerRequest{
+		Image: image,
+		Env:   brokerEnv,
+		// advertised listener needs the mapped port, known after start
+		Entrypoint:   utils.StarterEntrypoint(),
+		WaitingFor:   wait.ForLog("Kafka Server started"),
+		ExposedPorts: []string{"9092/tcp"},
+		LifecycleHooks: []testcontainers.ContainerLifecycleHooks{
+			utils.StarterHook(starterScript(alias)),
+		},
+		Labels: utils.Labels("kafka"),
+	}
+}
+
+func brokerAlias(nodeID int) string {
+	return "kafka-" + strconv.Itoa(nodeID)
+}
+
+// starterScript starts Kafka with the PLAINTEXT listener advertised on the mapped host port.
+func starterScript(alias string) func(ctx context.Context, c testcontainers.Container) (string, error) {
+	return func(ctx context.Context, c testcontainers.Container) (string, error) {
+		address, err := utils.MappedAddress(ctx, c, "9092/tcp")
+		if err != nil {
+			return "", err
+		}
+
+		return "#!/bin/bash\n" +
+			"export KAFKA_CFG_ADVERTISED_LISTENERS=PLAINTEXT://" + address + ",INTERNAL://" + alias + ":9094\n" +
+			"exec /opt/bitnami/scripts/kafka/entrypoint.sh /opt/bitnami/scripts/kafka/run.sh\n", nil
+	}
+}
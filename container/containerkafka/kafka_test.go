@@ -1,10 +1,14 @@
 package containerkafka_test
 
 import (
+	"os"
 	"testing"
+	"time"
 
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 	container "github.com/worldline-go/test/container/containerkafka"
+	"github.com/worldline-go/test/utils/kafkautils"
 )
 
 type KafkaSuite struct {
@@ -20,10 +24,156 @@ func TestExampleTestSuiteKafka(t *testing.T) {
 	suite.Run(t, new(KafkaSuite))
 }
 
+func TestCluster(t *testing.T) {
+	if os.Getenv("KAFKA_BROKER") != "" {
+		t.Skip("KAFKA_BROKER env uses an existing broker")
+	}
+
+	c := container.New(t, container.WithBrokers(3))
+	defer c.Stop(t)
+
+	c.CreateTopics(t, kafkautils.Topic{Name: "test-replicated", Partitions: 2, ReplicationFactor: 3})
+
+	topics, err := c.Admin.ListTopics(t.Context(), "test-replicated")
+	require.NoError(t, err)
+
+	topic := topics["test-replicated"]
+	require.NoError(t, topic.Err)
+	require.Len(t, topic.Partitions, 2)
+
+	for _, partition := range topic.Partitions {
+		require.Len(t, partition.Replicas, 3)
+	}
+
+	c.Mark(t, "test-replicated")
+	c.Publish(t, "test-replicated", []byte("replicated"))
+
+	records := c.Consume(t, "test-replicated", 1, 30*time.Second)
+	require.Equal(t, "replicated", string(records[0].Value))
+}
+
+func TestFake(t *testing.T) {
+	if os.Getenv("KAFKA_BROKER") != "" {
+		t.Skip("KAFKA_BROKER env uses an existing broker")
+	}
+
+	c := container.New(t, container.WithFake())
+	defer c.Stop(t)
+
+	c.CreateTopics(t, kafkautils.Topic{Name: "test-fake", Partitions: 3})
+
+	topics, err := c.Admin.ListTopics(t.Context(), "test-fake")
+	require.NoError(t, err)
+	require.Len(t, topics["test-fake"].Partitions, 3)
+
+	c.Mark(t, "test-fake")
+	c.Publish(t, "test-fake", []byte("first"), []byte("second"))
+
+	records := c.Consume(t, "test-fake", 2, 10*time.Second)
+	require.ElementsMatch(t, []string{"first", "second"}, []string{string(records[0].Value), string(records[1].Value)})
+
+	// topics must be marked before the messages are produced
+	mark, err := c.Kafka.Mark(t.Context(), "test-fake-other")
+	require.NoError(t, err)
+
+	_, err = mark.Consume(t.Context(), "test-fake", 1, time.Second)
+	require.ErrorIs(t, err, kafkautils.ErrNotMarked)
+}
+
 func (s *KafkaSuite) TearDownSuite() {
 	s.container.Stop(s.T())
 }
 
+func (s *KafkaSuite) TestConsume() {
+	t := s.T()
+
+	type event struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+
+	s.container.CreateTopics(t, kafkautils.Topic{Name: "test-consume", Partitions: 2})
+	s.container.Publish(t, "test-consume", event{ID: 1, Name: "before"})
+
+	s.container.Mark(t, "test-consume")
+	s.container.Publish(t, "test-consume", event{ID: 2, Name: "first"}, event{ID: 3, Name: "second"})
+
+	records := s.container.Consume(t, "test-consume", 1, 10*time.Second)
+	s.Len(records, 1)
+
+	got := kafkautils.WaitForJSON(t, s.container.KafkaTest, "test-consume", func(e event) bool {
+		return e.ID != 1
+	}, 10*time.Second)
+	s.NotEqual(1, got.ID)
+
+	s.True(s.container.AssertNoMessages(t, "test-consume", time.Second))
+}
+
+func (s *KafkaSuite) TestConsumeMarkPerTest() {
+	s.container.CreateTopics(s.T(), kafkautils.Topic{Name: "test-consume-mark", Partitions: 1})
+
+	s.Run("first", func() {
+		t := s.T()
+
+		s.container.Mark(t, "test-consume-mark")
+		s.container.Publish(t, "test-consume-mark", []byte("first"))
+
+		s.Run("second", func() {
+			t := s.T()
+
+			// messages of the first test are before the mark of this test
+			s.container.Mark(t, "test-consume-mark")
+			s.True(s.container.AssertNoMessages(t, "test-consume-mark", time.Second))
+		})
+
+		// mark of the first test is not moved by the second test
+		records := s.container.Consume(t, "test-consume-mark", 1, 10*time.Second)
+		s.Equal("first", string(records[0].Value))
+	})
+
+	// handle of the context API
+	mark, err := s.container.Kafka.Mark(s.T().Context(), "test-consume-mark")
+	s.Require().NoError(err)
+
+	s.container.Publish(s.T(), "test-consume-mark", []byte("handle"))
+
+	records, err := mark.Consume(s.T().Context(), "test-consume-mark", 1, 10*time.Second)
+	s.Require().NoError(err)
+	s.Equal("handle", string(records[0].Value))
+}
+
+func (s *KafkaSuite) TestCreateTopicsFile() {
+	t := s.T()
+
+	topics := s.container.CreateTopicsFile(t, "testdata/topics.yaml")
+	s.Len(topics, 2)
+
+	// existing topics are updated
+	s.container.CreateTopics(t, kafkautils.Topic{
+		Name:    "test-retention",
+		Configs: map[string]string{"retention.ms": "120000"},
+	})
+
+	configs, err := s.container.Admin.DescribeTopicConfigs(t.Context(), "test-compacted", "test-retention")
+	s.Require().NoError(err)
+
+	value := func(topic, key string) string {
+		resource, err := configs.On(topic, nil)
+		s.Require().NoError(err)
+
+		for _, config := range resource.Configs {
+			if config.Key == key {
+				return config.MaybeValue()
+			}
+		}
+
+		return ""
+	}
+
+	s.Equal("compact", value("test-compacted", "cleanup.policy"))
+	s.Equal("120000", value("test-retention", "retention.ms"))
+}
+
 // func (s *KafkaSuite) TestTo() {
 // 	s.T().Log("TestX")
 // }
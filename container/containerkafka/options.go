@@ -0,0 +1,36 @@
+package containerkafka
+
+type Option func(o *option)
+
+type option struct {
+	Brokers int
+	Fake    bool
+}
+
+func (o *option) apply(opts ...Option) {
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if o.Brokers < 1 {
+		o.Brokers = 1
+	}
+}
+
+// WithBrokers starts a KRaft cluster with n combined controller and broker nodes, default is 1.
+//   - Internal topics are replicated up to 3 brokers.
+//   - Ignored when KAFKA_BROKER env is set.
+func WithBrokers(n int) Option {
+	return func(o *option) {
+		o.Brokers = n
+	}
+}
+
+// WithFake runs an in-process kfake cluster instead of the Kafka container, no Docker needed.
+//   - KAFKA_BACKEND=kfake env selects it too.
+//   - Ignored when KAFKA_BROKER env is set.
+func WithFake() Option {
+	return func(o *option) {
+		o.Fake = true
+	}
+}
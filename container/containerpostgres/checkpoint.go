@@ -0,0 +1,76 @@
+package containerpostgres
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+)
+
+const checkpointName = "testdb_checkpoint"
+
+// Checkpoint snapshots the database on the first call and restores it when the test ends.
+//   - Call it in SetupTest, the first test takes the snapshot after the suite setup.
+//   - Not usable with parallel tests, they share the same database.
+func (p *Container) Checkpoint(t *testing.T) {
+	t.Helper()
+
+	p.mu.Lock()
+	if !p.checkpoint {
+		if err := p.snapshot(t, checkpointName); err != nil {
+			p.mu.Unlock()
+			t.Fatal(err)
+		}
+
+		p.checkpoint = true
+	}
+	p.mu.Unlock()
+
+	t.Cleanup(func() {
+		if err := p.restore(t, checkpointName); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+// Snapshot takes a named snapshot of the database, existing snapshot with the same name is overwritten.
+func (p *Container) Snapshot(t *testing.T, name string) {
+	t.Helper()
+
+	if err := p.snapshot(t, name); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Restore restores the database to the named snapshot.
+func (p *Container) Restore(t *testing.T, name string) {
+	t.Helper()
+
+	if err := p.restore(t, name); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (p *Container) snapshot(t *testing.T, name string) error {
+	t.Helper()
+	t.Logf("create snapshot %s", name)
+
+	// t.Context is canceled in cleanup
+	if err := p.CreateSnapshot(context.Background(), postgres.WithSnapshotName(name)); err != nil {
+		return fmt.Errorf("could not create snapshot %s: %w", name, err)
+	}
+
+	return nil
+}
+
+func (p *Container) restore(t *testing.T, name string) error {
+	t.Helper()
+	t.Logf("restore snapshot %s", name)
+
+	if err := p.RestoreSnapshot(context.Background(), postgres.WithSnapshotName(name)); err != nil {
+		return fmt.Errorf("could not restore snapshot %s: %w", name, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,235 @@
+package containerpostgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/worldline-go/test/utils/dbutils"
+)
+
+// Database is an isolated database cloned from a template database.
+type Database struct {
+	*dbutils.DatabaseTest
+
+	name string
+	dsn  string
+
+	sql *sql.DB
+}
+
+func (d *Database) Sql() *sql.DB {
+	return d.sql
+}
+
+func (d *Database) Name() string {
+	return d.name
+}
+
+func (d *Database) DSN() string {
+	return d.dsn
+}
+
+// NewDatabase creates a fresh database for the test cloned from a template database.
+//   - Init files are executed once into the template, later calls with the same file contents only clone it.
+//   - Database is dropped in t.Cleanup, so tests can use t.Parallel(). Templates are dropped in Stop.
+func (p *Container) NewDatabase(t *testing.T, opts ...OptionDatabase) *Database {
+	t.Helper()
+
+	o := optionDatabase{}
+	o.apply(opts...)
+
+	name, err := p.createDatabase(t, &o)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		// t.Context is already canceled when cleanup runs
+		if err := dropDatabase(context.Background(), p.sql, name); err != nil {
+			t.Errorf("could not drop database %s: %v", name, err)
+		}
+	})
+
+	dsn, err := dsnWithDatabase(p.dsn, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dbSql, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatalf("could not connect to database %s: %v", name, err)
+	}
+
+	// registered after drop, so it runs before it
+	t.Cleanup(func() {
+		if err := dbSql.Close(); err != nil {
+			t.Errorf("could not close sql connection: %v", err)
+		}
+	})
+
+	if err := dbSql.PingContext(t.Context()); err != nil {
+		t.Fatalf("could not ping to database %s: %v", name, err)
+	}
+
+	return &Database{
+		DatabaseTest: dbutils.NewTest(t, dbSql, dbutils.WithDSN(dsn)),
+		name:         name,
+		dsn:          dsn,
+		sql:          dbSql,
+	}
+}
+
+func (p *Container) createDatabase(t *testing.T, o *optionDatabase) (string, error) {
+	t.Helper()
+
+	// template creation and cloning are serialized, postgres rejects cloning a template in use
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	templateName, err := p.template(t, o)
+	if err != nil {
+		return "", err
+	}
+
+	name := p.NameGen(p.prefix)
+
+	t.Logf("create database %s from template %s", name, templateName)
+
+	if _, err := p.sql.ExecContext(t.Context(), "CREATE DATABASE "+name+" TEMPLATE "+templateName); err != nil {
+		return "", fmt.Errorf("could not create database %s: %w", name, err)
+	}
+
+	return name, nil
+}
+
+// template returns the template database of the init files, creating it on first use.
+func (p *Container) template(t *testing.T, o *optionDatabase) (string, error) {
+	t.Helper()
+
+	key, err := templateKey(o.Files, o.Values)
+	if err != nil {
+		return "", err
+	}
+
+	if name, ok := p.templates[key]; ok {
+		return name, nil
+	}
+
+	name := p.prefix + "_template_" + key
+
+	t.Logf("create template database %s", name)
+
+	if _, err := p.sql.ExecContext(t.Context(), "CREATE DATABASE "+name); err != nil {
+		return "", fmt.Errorf("could not create template database %s: %w", name, err)
+	}
+
+	if err := p.initTemplate(t.Context(), name, o); err != nil {
+		if errDrop := dropDatabase(context.Background(), p.sql, name); errDrop != nil {
+			t.Logf("could not drop template database %s: %v", name, errDrop)
+		}
+
+		return "", err
+	}
+
+	if p.templates == nil {
+		p.templates = make(map[string]string)
+	}
+
+	p.templates[key] = name
+
+	return name, nil
+}
+
+func (p *Container) initTemplate(ctx context.Context, name string, o *optionDatabase) error {
+	if len(o.Files) == 0 {
+		return nil
+	}
+
+	dsn, err := dsnWithDatabase(p.dsn, name)
+	if err != nil {
+		return err
+	}
+
+	dbSql, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return fmt.Errorf("could not connect to template database %s: %w", name, err)
+	}
+
+	// connections must be closed before the template can be cloned
+	defer dbSql.Close()
+
+	return dbutils.New(dbSql).ExecuteFiles(o.Files, dbutils.WithValues(o.Values), dbutils.WithExecContext(ctx))
+}
+
+// templateKey hashes the content of the init files and the values, so an edited file gets a new template.
+func templateKey(files []string, values map[string]string) (string, error) {
+	h := fnv.New64a()
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return "", fmt.Errorf("could not read file %s: %w", file, err)
+		}
+
+		h.Write(content)
+		h.Write([]byte{0})
+	}
+
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+
+	slices.Sort(keys)
+
+	for _, k := range keys {
+		h.Write([]byte(k + "=" + values[k]))
+		h.Write([]byte{0})
+	}
+
+	return strconv.FormatUint(h.Sum64(), 36), nil
+}
+
+// dropTemplates drops the template databases of NewDatabase.
+func (p *Container) dropTemplates(ctx context.Context, db *sql.DB) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var errs []error
+	for key, name := range p.templates {
+		if err := dropDatabase(ctx, db, name); err != nil {
+			errs = append(errs, fmt.Errorf("could not drop template database %s: %w", name, err))
+
+			continue
+		}
+
+		delete(p.templates, key)
+	}
+
+	return errors.Join(errs...)
+}
+
+// dropDatabase drops the database with the connections still open on it, like a leaked rows.
+func dropDatabase(ctx context.Context, db *sql.DB, name string) error {
+	_, err := db.ExecContext(ctx, "DROP DATABASE IF EXISTS "+name+" WITH (FORCE)")
+
+	return err
+}
+
+func dsnWithDatabase(dsn, name string) (string, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return "", fmt.Errorf("could not parse dsn: %w", err)
+	}
+
+	u.Path = "/" + name
+
+	return u.String(), nil
+}
@@ -0,0 +1,42 @@
+package containerpostgres_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/worldline-go/test/container/containerpostgres"
+)
+
+func TestTemplateKey(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "init.sql")
+	require.NoError(t, os.WriteFile(file, []byte("CREATE TABLE t (id int);"), 0o600))
+
+	key := func(files []string, values map[string]string) string {
+		t.Helper()
+
+		key, err := containerpostgres.TemplateKey(files, values)
+		require.NoError(t, err)
+
+		return key
+	}
+
+	base := key([]string{file}, nil)
+
+	// same content under another path shares the template
+	other := filepath.Join(dir, "other.sql")
+	require.NoError(t, os.WriteFile(other, []byte("CREATE TABLE t (id int);"), 0o600))
+	require.Equal(t, base, key([]string{other}, nil))
+
+	// edited file gets a new template
+	require.NoError(t, os.WriteFile(file, []byte("CREATE TABLE t (id bigint);"), 0o600))
+	require.NotEqual(t, base, key([]string{file}, nil))
+
+	require.NotEqual(t, key([]string{file}, map[string]string{"a": "1"}), key([]string{file}, map[string]string{"a": "2"}))
+
+	_, err := containerpostgres.TemplateKey([]string{filepath.Join(dir, "missing.sql")}, nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,36 @@
+package containerpostgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+
+	"github.com/worldline-go/test/utils"
+)
+
+// EnvName is the container name of the testenv command.
+const EnvName = "testenv-postgres"
+
+// StartEnv starts the postgres container of the local dev environment, or attaches to the running one.
+//   - Container is kept running, disable the testcontainers reaper to keep it after the process exits.
+//   - Returns the TEST_POSTGRES_DSN env picked up by New, each New creates its own database in it.
+func StartEnv(ctx context.Context) (map[string]string, error) {
+	opts := append(defaultOptions(),
+		testcontainers.WithLabels(map[string]string{utils.LabelEnv: "true"}),
+		testcontainers.WithReuseByName(EnvName),
+	)
+
+	container, err := postgres.Run(ctx, postgresImage(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("could not create postgres container: %w", err)
+	}
+
+	dsn, err := container.ConnectionString(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not get postgres dsn: %w", err)
+	}
+
+	return map[string]string{"TEST_POSTGRES_DSN": dsn}, nil
+}
@@ -0,0 +1,7 @@
+package containerpostgres
+
+// SharedName exposes sharedName to the tests.
+var SharedName = sharedName
+
+// TemplateKey exposes templateKey to the tests.
+var TemplateKey = templateKey
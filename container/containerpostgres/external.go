@@ -0,0 +1,97 @@
+package containerpostgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/worldline-go/test/utils/dbutils"
+)
+
+// ErrSnapshotUnsupported is returned by the snapshot methods on an external or shared server.
+var ErrSnapshotUnsupported = errors.New("snapshots are not supported on an external or shared postgres server, they need an own container")
+
+// newExternal uses an existing server, like the TEST_POSTGRES_DSN env or a shared container.
+//   - A database is created for the run and dropped in Stop with the databases of NewDatabase.
+//   - Database names have a random prefix, runs of other packages can share the server.
+func newExternal(t *testing.T, serverDSN string) *Container {
+	t.Helper()
+
+	u, err := url.Parse(serverDSN)
+	if err != nil {
+		t.Fatalf("could not parse postgres dsn, it must be an url: %v", err)
+	}
+
+	address := u.Host
+	if u.Port() == "" {
+		address = net.JoinHostPort(u.Hostname(), "5432")
+	}
+
+	admin, err := sql.Open("pgx", serverDSN)
+	if err != nil {
+		t.Fatalf("could not connect to postgres: %v", err)
+	}
+
+	if err := admin.PingContext(t.Context()); err != nil {
+		admin.Close()
+		t.Fatalf("could not ping to postgres: %v", err)
+	}
+
+	prefix := "test_" + strings.ReplaceAll(uuid.NewString(), "-", "")[:8]
+
+	t.Logf("postgres host: %s", address)
+	t.Logf("create run database %s", prefix)
+
+	if _, err := admin.ExecContext(t.Context(), "CREATE DATABASE "+prefix); err != nil {
+		admin.Close()
+		t.Fatalf("could not create run database %s: %v", prefix, err)
+	}
+
+	result := &Container{
+		address: address,
+		admin:   admin,
+		prefix:  prefix,
+	}
+
+	result.dsn, err = dsnWithDatabase(serverDSN, prefix)
+	if err != nil {
+		result.Stop(t)
+		t.Fatal(err)
+	}
+
+	result.sql, err = sql.Open("pgx", result.dsn)
+	if err != nil {
+		result.Stop(t)
+		t.Fatalf("could not connect to postgres: %v", err)
+	}
+
+	if err := result.sql.PingContext(t.Context()); err != nil {
+		result.Stop(t)
+		t.Fatalf("could not ping to postgres: %v", err)
+	}
+
+	result.DatabaseTest = dbutils.NewTest(t, result.sql, dbutils.WithDSN(result.dsn))
+
+	return result
+}
+
+// dropRunDatabases drops the templates and the run database on the external server.
+func (p *Container) dropRunDatabases() error {
+	// t.Context is canceled in cleanup, Stop is mostly called there
+	ctx := context.Background()
+
+	errTemplates := p.dropTemplates(ctx, p.admin)
+
+	if err := dropDatabase(ctx, p.admin, p.prefix); err != nil {
+		return errors.Join(errTemplates, fmt.Errorf("could not drop database %s: %w", p.prefix, err))
+	}
+
+	return errTemplates
+}
@@ -0,0 +1,29 @@
+package containerpostgres
+
+type OptionDatabase func(o *optionDatabase)
+
+type optionDatabase struct {
+	Files  []string
+	Values map[string]string
+}
+
+func (o *optionDatabase) apply(opts ...OptionDatabase) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// WithInitFiles sets the files executed once into the template database.
+//   - Databases with the same init files and values share the same template.
+func WithInitFiles(files ...string) OptionDatabase {
+	return func(o *optionDatabase) {
+		o.Files = append(o.Files, files...)
+	}
+}
+
+// WithInitValues sets os.Expand values inside the init files content.
+func WithInitValues(values map[string]string) OptionDatabase {
+	return func(o *optionDatabase) {
+		o.Values = values
+	}
+}
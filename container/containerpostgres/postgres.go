@@ -3,11 +3,15 @@ package containerpostgres
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
+	"fmt"
 	"os"
+	"sync"
 	"testing"
 
 	"github.com/testcontainers/testcontainers-go"
 
+	"github.com/jackc/pgx/v5/pgxpool"
 	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/testcontainers/testcontainers-go/modules/postgres"
 	"github.com/testcontainers/testcontainers-go/wait"
@@ -25,18 +29,52 @@ type Container struct {
 	address string
 	dsn     string
 
-	sql *sql.DB
+	sql  *sql.DB
+	pool *pgxpool.Pool
+
+	// admin is the connection of the TEST_POSTGRES_DSN server, nil with the container
+	admin *sql.DB
+	// prefix of the database names, the run database name on an external server
+	prefix string
+
+	// template databases of NewDatabase by init files key
+	mu        sync.Mutex
+	templates map[string]string
+
+	// checkpoint is taken by the first Checkpoint call
+	checkpoint bool
 }
 
 func (p *Container) Stop(t *testing.T) {
 	t.Helper()
 
+	if p.pool != nil {
+		p.pool.Close()
+	}
+
 	if p.sql != nil {
+		// templates of the container live next to the default database, external ones are dropped with admin
+		if p.admin == nil {
+			if err := p.dropTemplates(context.Background(), p.sql); err != nil {
+				t.Errorf("could not drop template databases: %v", err)
+			}
+		}
+
 		if err := p.sql.Close(); err != nil {
 			t.Errorf("could not close sql connection: %v", err)
 		}
 	}
 
+	if p.admin != nil {
+		if err := p.dropRunDatabases(); err != nil {
+			t.Errorf("could not drop run databases: %v", err)
+		}
+
+		if err := p.admin.Close(); err != nil {
+			t.Errorf("could not close admin connection: %v", err)
+		}
+	}
+
 	if p.container != nil {
 		if err := p.container.Terminate(t.Context()); err != nil {
 			t.Fatalf("could not stop postgres container: %v", err)
@@ -48,6 +86,33 @@ func (p *Container) Sql() *sql.DB {
 	return p.sql
 }
 
+// Pool returns the pgx pool of the database, created on the first call and closed in Stop.
+func (p *Container) Pool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.pool != nil {
+		return p.pool
+	}
+
+	// pool outlives the test context
+	pool, err := pgxpool.New(context.Background(), p.dsn)
+	if err != nil {
+		t.Fatalf("could not create pgx pool: %v", err)
+	}
+
+	if err := pool.Ping(t.Context()); err != nil {
+		pool.Close()
+		t.Fatalf("could not ping to postgres: %v", err)
+	}
+
+	p.pool = pool
+
+	return pool
+}
+
 func (p *Container) Address() string {
 	return p.address
 }
@@ -56,27 +121,33 @@ func (p *Container) DSN() string {
 	return p.dsn
 }
 
+// New starts a postgres container.
+//   - TEST_POSTGRES_DSN env uses an existing server instead, with a database created for the run.
+//     The DSN must be an url, like postgres://postgres@localhost:5432/postgres?sslmode=disable.
+//   - TEST_SHARED=true env shares the container with the other packages of the session,
+//     each package uses its own database in it like with TEST_POSTGRES_DSN.
+//     Init scripts and lifecycle hooks are rejected, they can't be applied to the package database.
 func New(t *testing.T, opts ...testcontainers.ContainerCustomizer) *Container {
 	t.Helper()
 
-	image := DefaultPostgresImage
-	if v := os.Getenv("TEST_IMAGE_POSTGRES"); v != "" {
-		image = v
+	if v := os.Getenv("TEST_POSTGRES_DSN"); v != "" {
+		return newExternal(t, v)
 	}
 
-	// Create options slice with defaults
-	defaultOpts := []testcontainers.ContainerCustomizer{
-		postgres.WithDatabase("testdb"),
-		testcontainers.WithEnv(map[string]string{
-			"POSTGRES_HOST_AUTH_METHOD": "trust",
-		}),
-		postgres.WithSQLDriver("pgx"),
-		testcontainers.WithWaitStrategy(wait.ForLog("database system is ready to accept connections").WithOccurrence(2)),
-		testcontainers.WithLabels(utils.EnvToLabels()),
-	}
+	image := postgresImage()
 
 	// Merge custom options with defaults
-	allOpts := append(defaultOpts, opts...)
+	allOpts := append(defaultOptions(), opts...)
+
+	shared := utils.Shared()
+	if shared {
+		name, err := sharedName(image, allOpts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		allOpts = append(allOpts, testcontainers.WithReuseByName(name))
+	}
 
 	// Run with merged options
 	postgresContainer, err := postgres.Run(t.Context(), image, allOpts...)
@@ -95,6 +166,11 @@ func New(t *testing.T, opts ...testcontainers.ContainerCustomizer) *Container {
 		t.Fatal(err)
 	}
 
+	if shared {
+		// terminated by the reaper when the session ends
+		return newExternal(t, connStr)
+	}
+
 	t.Logf("postgres host: %s", addr)
 	t.Logf("postgres dsn: %s", connStr)
 
@@ -113,14 +189,109 @@ func New(t *testing.T, opts ...testcontainers.ContainerCustomizer) *Container {
 		address:      addr,
 		dsn:          connStr,
 		sql:          dbSql,
-		DatabaseTest: dbutils.NewTest(t, dbSql),
+		prefix:       "testdb",
+		DatabaseTest: dbutils.NewTest(t, dbSql, dbutils.WithDSN(connStr)),
 	}
 }
 
-func (p *Container) CreateSnapshot(ctx context.Context) error {
-	return p.container.Snapshot(ctx)
+// postgresImage returns the TEST_IMAGE_POSTGRES env, default is DefaultPostgresImage.
+func postgresImage() string {
+	if v := os.Getenv("TEST_IMAGE_POSTGRES"); v != "" {
+		return v
+	}
+
+	return DefaultPostgresImage
+}
+
+// defaultOptions returns the container options of New before the custom ones.
+func defaultOptions() []testcontainers.ContainerCustomizer {
+	return []testcontainers.ContainerCustomizer{
+		postgres.WithDatabase("testdb"),
+		testcontainers.WithEnv(map[string]string{
+			"POSTGRES_HOST_AUTH_METHOD": "trust",
+		}),
+		postgres.WithSQLDriver("pgx"),
+		testcontainers.WithWaitStrategy(wait.ForLog("database system is ready to accept connections").WithOccurrence(2)),
+		testcontainers.WithLabels(utils.Labels("postgres")),
+	}
+}
+
+// CreateSnapshot takes a snapshot of the database, use postgres.WithSnapshotName to name it.
+//   - Idle connections of Sql and Pool are closed, the database must not be in use to be copied.
+//   - Close other handles of the database first, like IsolatedSchema, NewPgx or BeginTx, otherwise it fails.
+//   - Returns ErrSnapshotUnsupported on an external or shared server.
+func (p *Container) CreateSnapshot(ctx context.Context, opts ...postgres.SnapshotOption) error {
+	if p.container == nil {
+		return ErrSnapshotUnsupported
+	}
+
+	p.releaseConnections(ctx)
+
+	if err := p.checkConnections(ctx); err != nil {
+		return err
+	}
+
+	return p.container.Snapshot(ctx, opts...)
+}
+
+// RestoreSnapshot restores the database to the last or the named snapshot.
+//   - Idle connections of Sql and Pool are closed, other connections of the database are terminated.
+//   - Returns ErrSnapshotUnsupported on an external or shared server.
+func (p *Container) RestoreSnapshot(ctx context.Context, opts ...postgres.SnapshotOption) error {
+	if p.container == nil {
+		return ErrSnapshotUnsupported
+	}
+
+	p.releaseConnections(ctx)
+
+	return p.container.Restore(ctx, opts...)
+}
+
+// releaseConnections closes the idle connections of Sql and Pool, new ones are opened on demand.
+//   - Connection limits set by the caller are kept.
+func (p *Container) releaseConnections(ctx context.Context) {
+	if p.pool != nil {
+		p.pool.Reset()
+	}
+
+	for range p.sql.Stats().Idle {
+		conn, err := p.sql.Conn(ctx)
+		if err != nil {
+			return
+		}
+
+		discardConn(conn)
+	}
 }
 
-func (p *Container) RestoreSnapshot(ctx context.Context) error {
-	return p.container.Restore(ctx)
+// checkConnections returns an error if other connections use the database.
+func (p *Container) checkConnections(ctx context.Context) error {
+	conn, err := p.sql.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("could not connect to postgres: %w", err)
+	}
+	// connection must not stay in the pool, the database is copied
+	defer discardConn(conn)
+
+	var count int
+	if err := conn.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM pg_stat_activity WHERE datname = current_database() AND pid <> pg_backend_pid()",
+	).Scan(&count); err != nil {
+		return fmt.Errorf("could not check connections: %w", err)
+	}
+
+	if count > 0 {
+		return fmt.Errorf("database is used by %d other connections, close the handles like IsolatedSchema, NewPgx or BeginTx before the snapshot", count)
+	}
+
+	return nil
+}
+
+// discardConn closes the connection instead of returning it to the pool.
+func discardConn(conn *sql.Conn) {
+	_ = conn.Raw(func(any) error {
+		return driver.ErrBadConn
+	})
+
+	_ = conn.Close()
 }
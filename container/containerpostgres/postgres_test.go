@@ -1,14 +1,24 @@
 package containerpostgres_test
 
 import (
+	"embed"
+	"errors"
+	"os"
+	"strconv"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 
 	"github.com/worldline-go/test/container/containerpostgres"
+	"github.com/worldline-go/test/utils"
+	"github.com/worldline-go/test/utils/dbutils"
 )
 
+//go:embed testdata/*.sql testdata/folder
+var testdataFS embed.FS
+
 type PostgresSuite struct {
 	suite.Suite
 	container *containerpostgres.Container
@@ -35,3 +45,265 @@ func (s *PostgresSuite) TearDownTest() {
 	_, err := s.container.Sql().Exec(sql)
 	require.NoError(s.T(), err)
 }
+
+func (s *PostgresSuite) TestNewDatabase() {
+	db1 := s.container.NewDatabase(s.T(), containerpostgres.WithInitFiles("testdata/init.sql"))
+	db2 := s.container.NewDatabase(s.T(), containerpostgres.WithInitFiles("testdata/init.sql"))
+
+	_, err := db1.Sql().Exec("INSERT INTO transaction.events (name) VALUES ('isolated')")
+	require.NoError(s.T(), err)
+
+	var count int
+	require.NoError(s.T(), db2.Sql().QueryRow("SELECT COUNT(*) FROM transaction.events").Scan(&count))
+	require.Equal(s.T(), 0, count)
+}
+
+func (s *PostgresSuite) TestIsolatedSchema() {
+	schema := s.container.IsolatedSchema(s.T(), "isolated")
+
+	_, err := schema.Sql().Exec("CREATE TABLE isolated_events (id INT)")
+	require.NoError(s.T(), err)
+
+	var tableSchema string
+	require.NoError(s.T(), schema.Sql().QueryRow(
+		"SELECT table_schema FROM information_schema.tables WHERE table_name = 'isolated_events'",
+	).Scan(&tableSchema))
+	require.Equal(s.T(), schema.Name, tableSchema)
+}
+
+func (s *PostgresSuite) TestMigrate() {
+	db := s.container.NewDatabase(s.T())
+
+	db.MigrateVerify(s.T(), "testdata/migrations")
+	require.Equal(s.T(), int64(2), db.MigrationVersion(s.T()))
+
+	db.MigrateTo(s.T(), "testdata/migrations", 1)
+	require.Equal(s.T(), int64(1), db.MigrationVersion(s.T()))
+
+	// golang-migrate layout keeps only the current version
+	var version int64
+	var dirty bool
+	require.NoError(s.T(), db.Sql().QueryRow("SELECT version, dirty FROM schema_migrations").Scan(&version, &dirty))
+	require.Equal(s.T(), int64(1), version)
+	require.False(s.T(), dirty)
+
+	db.Rollback(s.T(), "testdata/migrations")
+	require.Equal(s.T(), int64(0), db.MigrationVersion(s.T()))
+}
+
+func (s *PostgresSuite) TestMigrateGoose() {
+	db := s.container.NewDatabase(s.T())
+
+	db.MigrateTo(s.T(), "testdata/migrations_goose", 2)
+	require.Equal(s.T(), int64(2), db.MigrationVersion(s.T()))
+
+	// goose layout keeps a row per applied version
+	var versions []int64
+	rows, err := db.Sql().Query("SELECT version_id FROM goose_db_version WHERE is_applied ORDER BY id")
+	require.NoError(s.T(), err)
+	defer rows.Close()
+
+	for rows.Next() {
+		var v int64
+		require.NoError(s.T(), rows.Scan(&v))
+		versions = append(versions, v)
+	}
+	require.NoError(s.T(), rows.Err())
+	require.Equal(s.T(), []int64{0, 1, 2}, versions)
+
+	db.MigrateTo(s.T(), "testdata/migrations_goose", 1)
+	require.Equal(s.T(), int64(1), db.MigrationVersion(s.T(), dbutils.WithMigrateFormat(dbutils.MigrateGoose)))
+}
+
+func (s *PostgresSuite) TestExecuteFolderFS() {
+	db := s.container.NewDatabase(s.T())
+
+	db.ExecuteFolderFS(s.T(), testdataFS, "testdata", dbutils.WithInclude("init.sql"))
+
+	var count int
+	require.NoError(s.T(), db.Sql().QueryRow("SELECT COUNT(*) FROM transaction.events").Scan(&count))
+	require.Equal(s.T(), 0, count)
+}
+
+func (s *PostgresSuite) TestExecuteFolder() {
+	// 2_table runs before 10_insert, nested files after the files of the folder
+	want := []map[string]any{{"name": "10_insert"}, {"name": "nested/1_insert"}}
+
+	s.Run("fs", func() {
+		db := s.container.NewDatabase(s.T())
+		db.ExecuteFolderFS(s.T(), testdataFS, "testdata/folder", dbutils.WithExclude("broken.sql"))
+		db.AssertRows(s.T(), "SELECT name FROM folder.steps ORDER BY id", want)
+	})
+
+	s.Run("os", func() {
+		db := s.container.NewDatabase(s.T())
+		db.ExecuteFolder(s.T(), "testdata/folder", dbutils.WithExclude("broken.sql"))
+		db.AssertRows(s.T(), "SELECT name FROM folder.steps ORDER BY id", want)
+	})
+}
+
+func (s *PostgresSuite) TestLoadFixtures() {
+	db := s.container.NewDatabase(s.T(), containerpostgres.WithInitFiles("testdata/init.sql"))
+
+	db.LoadFixtures(s.T(), []string{"testdata/fixtures.yaml"}, dbutils.WithValues(map[string]string{"name": "second"}))
+
+	var id int
+	require.NoError(s.T(), db.Sql().QueryRow("INSERT INTO transaction.events (name) VALUES ('third') RETURNING id").Scan(&id))
+	require.Equal(s.T(), 3, id)
+
+	var name string
+	require.NoError(s.T(), db.Sql().QueryRow("SELECT name FROM transaction.events WHERE id = 2").Scan(&name))
+	require.Equal(s.T(), "second", name)
+}
+
+func (s *PostgresSuite) TestAssertRows() {
+	db := s.container.NewDatabase(s.T(), containerpostgres.WithInitFiles("testdata/init.sql"))
+
+	db.LoadFixtures(s.T(), []string{"testdata/fixtures.yaml"}, dbutils.WithValues(map[string]string{"name": "second"}))
+
+	db.AssertRowCount(s.T(), "transaction.events", "", 2)
+	db.AssertRowCount(s.T(), "transaction.events", "name = $1", 1, dbutils.WithArgs("first"))
+
+	db.AssertRows(s.T(), "SELECT id, name FROM transaction.events WHERE id > $1", []map[string]any{
+		{"name": "second"},
+		{"id": 1, "name": "first"},
+	}, dbutils.WithArgs(0), dbutils.WithUnordered())
+}
+
+func (s *PostgresSuite) TestMatchSnapshot() {
+	db := s.container.NewDatabase(s.T(), containerpostgres.WithInitFiles("testdata/init.sql"))
+
+	db.LoadFixtures(s.T(), []string{"testdata/fixtures.yaml"}, dbutils.WithValues(map[string]string{"name": "second"}))
+
+	db.MatchSnapshot(s.T(), "events", "SELECT id, name, created_at FROM transaction.events WHERE id > $1 ORDER BY id",
+		dbutils.WithArgs(0), dbutils.WithMask("created_at"),
+	)
+}
+
+func (s *PostgresSuite) TestSnapshot() {
+	if os.Getenv("TEST_POSTGRES_DSN") != "" || utils.Shared() {
+		s.T().Skip(containerpostgres.ErrSnapshotUnsupported)
+	}
+
+	s.container.Snapshot(s.T(), "seeded")
+
+	_, err := s.container.Sql().Exec("INSERT INTO transaction.events (name) VALUES ('temporary')")
+	require.NoError(s.T(), err)
+	s.container.AssertRowCount(s.T(), "transaction.events", "", 1)
+
+	s.container.Restore(s.T(), "seeded")
+	s.container.AssertRowCount(s.T(), "transaction.events", "", 0)
+}
+
+func (s *PostgresSuite) TestCheckpoint() {
+	if os.Getenv("TEST_POSTGRES_DSN") != "" || utils.Shared() {
+		s.T().Skip(containerpostgres.ErrSnapshotUnsupported)
+	}
+
+	_, err := s.container.Sql().Exec("INSERT INTO transaction.events (name) VALUES ('seeded')")
+	require.NoError(s.T(), err)
+
+	s.Run("modify", func() {
+		s.container.Checkpoint(s.T())
+
+		_, err := s.container.Sql().Exec("UPDATE transaction.events SET name = 'modified'")
+		require.NoError(s.T(), err)
+
+		_, err = s.container.Sql().Exec("INSERT INTO transaction.events (name) VALUES ('temporary')")
+		require.NoError(s.T(), err)
+		s.container.AssertRowCount(s.T(), "transaction.events", "", 2)
+	})
+
+	s.container.AssertRowCount(s.T(), "transaction.events", "name = $1", 1, dbutils.WithArgs("seeded"))
+	s.container.AssertRowCount(s.T(), "transaction.events", "", 1)
+}
+
+func (s *PostgresSuite) TestIsolatedTx() {
+	s.Run("rollback", func() {
+		tx := s.container.IsolatedTx(s.T())
+
+		// transactions of the code under test are savepoints
+		inner, err := tx.Sql().BeginTx(s.T().Context(), nil)
+		require.NoError(s.T(), err)
+
+		_, err = inner.Exec("INSERT INTO transaction.events (name) VALUES ('inner')")
+		require.NoError(s.T(), err)
+		require.NoError(s.T(), inner.Commit())
+
+		// failed statement doesn't abort the outer transaction
+		_, err = tx.Sql().Exec("INSERT INTO transaction.missing (name) VALUES ('missing')")
+		require.Error(s.T(), err)
+
+		tx.AssertRowCount(s.T(), "transaction.events", "", 1)
+	})
+
+	s.Run("concurrent", func() {
+		tx := s.container.IsolatedTx(s.T())
+
+		var wg sync.WaitGroup
+		errs := make(chan error, 10)
+		for i := range 10 {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				inner, err := tx.Sql().BeginTx(s.T().Context(), nil)
+				if err != nil {
+					errs <- err
+
+					return
+				}
+
+				if _, err := inner.Exec("INSERT INTO transaction.events (name) VALUES ($1)", strconv.Itoa(i)); err != nil {
+					errs <- errors.Join(err, inner.Rollback())
+
+					return
+				}
+
+				// rollback of one transaction must not touch the savepoints of the others
+				if i%2 == 0 {
+					errs <- inner.Rollback()
+
+					return
+				}
+
+				errs <- inner.Commit()
+			}()
+		}
+
+		wg.Wait()
+		close(errs)
+
+		for err := range errs {
+			require.NoError(s.T(), err)
+		}
+
+		tx.AssertRowCount(s.T(), "transaction.events", "", 5)
+	})
+
+	s.container.AssertRowCount(s.T(), "transaction.events", "", 0)
+}
+
+func (s *PostgresSuite) TestPool() {
+	pool := s.container.Pool(s.T())
+	require.Same(s.T(), pool, s.container.Pool(s.T()))
+
+	db := dbutils.NewTestPgx(s.T(), pool)
+	db.ExecuteFiles(s.T(), []string{"testdata/init.sql"})
+
+	_, err := pool.Exec(s.T().Context(), "INSERT INTO transaction.events (name) VALUES ($1)", "pool")
+	require.NoError(s.T(), err)
+
+	db.AssertRowCount(s.T(), "transaction.events", "name = $1", 1, dbutils.WithArgs("pool"))
+}
+
+func (s *PostgresSuite) TestDatabaseLiteral() {
+	// handle built without the constructor executes on DB
+	db := &dbutils.Database{DB: s.container.Sql()}
+
+	require.NoError(s.T(), db.CreateSchema("literal"))
+	require.NoError(s.T(), db.DropSchema("literal"))
+	require.NoError(s.T(), db.Close())
+	require.NoError(s.T(), s.container.Sql().Ping())
+}
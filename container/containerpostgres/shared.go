@@ -0,0 +1,143 @@
+package containerpostgres
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/testcontainers/testcontainers-go"
+
+	"github.com/worldline-go/test/utils"
+)
+
+// initScriptsDir is the folder of the scripts run by the postgres image on the first start.
+const initScriptsDir = "/docker-entrypoint-initdb.d/"
+
+// sharedName returns the shared container name keyed by the image and the customized request.
+//   - Files are keyed by their content, config modifiers by the configs they produce.
+//   - Init scripts and lifecycle hooks are rejected, packages use their own database in a shared container,
+//     scripts would run only in the default database and hooks can't be compared between packages.
+func sharedName(image string, opts []testcontainers.ContainerCustomizer) (string, error) {
+	req := testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{Image: image},
+	}
+
+	for _, opt := range opts {
+		if err := opt.Customize(&req); err != nil {
+			return "", fmt.Errorf("could not apply postgres options: %w", err)
+		}
+	}
+
+	if len(req.LifecycleHooks) > 0 {
+		return "", errors.New("lifecycle hooks are not supported with TEST_SHARED, the shared container can't be keyed by them")
+	}
+
+	for _, sub := range req.ImageSubstitutors {
+		substituted, err := sub.Substitute(req.Image)
+		if err != nil {
+			return "", fmt.Errorf("could not substitute image %s: %w", req.Image, err)
+		}
+
+		req.Image = substituted
+	}
+
+	files := make([]string, 0, len(req.Files))
+	for _, file := range req.Files {
+		if strings.HasPrefix(file.ContainerFilePath, initScriptsDir) {
+			return "", fmt.Errorf("init script %s is not supported with TEST_SHARED, it would run only in the default database, use WithInitFiles of NewDatabase or ExecuteFiles", file.ContainerFilePath)
+		}
+
+		digest, err := fileDigest(file)
+		if err != nil {
+			return "", err
+		}
+
+		files = append(files, fmt.Sprintf("%s:%o:%s", file.ContainerFilePath, file.FileMode, digest))
+	}
+
+	config := &container.Config{}
+	if req.ConfigModifier != nil {
+		req.ConfigModifier(config)
+	}
+
+	hostConfig := &container.HostConfig{}
+	if req.HostConfigModifier != nil {
+		req.HostConfigModifier(hostConfig)
+	}
+
+	endpoints := map[string]*network.EndpointSettings{}
+	if req.EndpointSettingsModifier != nil {
+		req.EndpointSettingsModifier(endpoints)
+	}
+
+	// pointers of the docker types are followed by json, fmt would print their addresses
+	modified, err := json.Marshal([]any{config, hostConfig, endpoints, req.Mounts, req.Resources})
+	if err != nil {
+		return "", fmt.Errorf("could not encode postgres options: %w", err)
+	}
+
+	return utils.SharedName("postgres", req.Image,
+		req.Env, req.Cmd, req.Entrypoint, req.ExposedPorts, req.Labels, req.Tmpfs,
+		req.Networks, req.NetworkAliases, req.NetworkMode, req.Hostname, req.WorkingDir, req.User,
+		req.ExtraHosts, req.Binds, req.CapAdd, req.CapDrop, req.Privileged, req.ShmSize, req.ImagePlatform,
+		files, string(modified),
+	), nil
+}
+
+// fileDigest returns the sha256 of the file content, a folder is hashed with its file paths and contents.
+//   - Reader of the file is rewound after reading, it is used again when the container starts.
+func fileDigest(file testcontainers.ContainerFile) (string, error) {
+	h := sha256.New()
+
+	if file.Reader != nil {
+		seeker, ok := file.Reader.(io.Seeker)
+		if !ok {
+			return "", fmt.Errorf("file %s is not supported with TEST_SHARED, its reader can't be rewound after hashing", file.ContainerFilePath)
+		}
+
+		if _, err := io.Copy(h, file.Reader); err != nil {
+			return "", fmt.Errorf("could not read file %s: %w", file.ContainerFilePath, err)
+		}
+
+		if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+			return "", fmt.Errorf("could not rewind file %s: %w", file.ContainerFilePath, err)
+		}
+
+		return hex.EncodeToString(h.Sum(nil)), nil
+	}
+
+	err := filepath.WalkDir(file.HostFilePath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(file.HostFilePath, path)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(h, "%s\x00%d\x00", filepath.ToSlash(rel), len(content))
+		h.Write(content)
+
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("could not read file %s: %w", file.HostFilePath, err)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,65 @@
+package containerpostgres_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+
+	"github.com/worldline-go/test/container/containerpostgres"
+)
+
+func TestSharedName(t *testing.T) {
+	t.Setenv("TEST_SESSION_ID", "session-1")
+
+	config := filepath.Join(t.TempDir(), "postgresql.conf")
+	require.NoError(t, os.WriteFile(config, []byte("max_connections = 100\n"), 0o600))
+
+	name := func(opts ...testcontainers.ContainerCustomizer) string {
+		t.Helper()
+
+		name, err := containerpostgres.SharedName("postgres:16", opts)
+		require.NoError(t, err)
+
+		return name
+	}
+
+	base := name(postgres.WithConfigFile(config))
+	require.Equal(t, base, name(postgres.WithConfigFile(config)))
+
+	// file content is part of the key
+	require.NoError(t, os.WriteFile(config, []byte("max_connections = 200\n"), 0o600))
+	require.NotEqual(t, base, name(postgres.WithConfigFile(config)))
+
+	// config modifiers are keyed by the config they produce
+	modifier := func(memory int64) testcontainers.ContainerCustomizer {
+		return testcontainers.WithHostConfigModifier(func(hostConfig *container.HostConfig) {
+			hostConfig.Memory = memory
+		})
+	}
+	require.Equal(t, name(modifier(1<<30)), name(modifier(1<<30)))
+	require.NotEqual(t, name(modifier(1<<30)), name(modifier(2<<30)))
+
+	// reader is rewound for the container start
+	reader := strings.NewReader("content")
+	name(testcontainers.WithFiles(testcontainers.ContainerFile{Reader: reader, ContainerFilePath: "/etc/file", FileMode: 0o644}))
+	require.Equal(t, len("content"), reader.Len())
+}
+
+func TestSharedNameRejected(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "init.sql")
+	require.NoError(t, os.WriteFile(script, []byte("CREATE TABLE t (id int);"), 0o600))
+
+	_, err := containerpostgres.SharedName("postgres:16", []testcontainers.ContainerCustomizer{postgres.WithInitScripts(script)})
+	require.ErrorContains(t, err, "init script")
+
+	_, err = containerpostgres.SharedName("postgres:16", []testcontainers.ContainerCustomizer{
+		testcontainers.WithLifecycleHooks(testcontainers.ContainerLifecycleHooks{}),
+	})
+	require.ErrorContains(t, err, "lifecycle hooks")
+}
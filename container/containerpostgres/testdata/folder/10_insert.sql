@@ -0,0 +1 @@
+INSERT INTO folder.steps (name) VALUES ('10_insert');
@@ -0,0 +1,6 @@
+CREATE SCHEMA IF NOT EXISTS folder;
+
+CREATE TABLE folder.steps (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL
+);
@@ -0,0 +1,2 @@
+-- excluded by the test
+THIS IS NOT SQL;
@@ -0,0 +1 @@
+INSERT INTO folder.steps (name) VALUES ('nested/1_insert');
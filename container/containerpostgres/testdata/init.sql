@@ -0,0 +1,7 @@
+CREATE SCHEMA IF NOT EXISTS transaction;
+
+CREATE TABLE IF NOT EXISTS transaction.events (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE accounts ADD COLUMN email TEXT;
+
+-- +goose Down
+ALTER TABLE accounts DROP COLUMN email;
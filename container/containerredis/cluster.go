@@ -0,0 +1,527 @@
+package containerredis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/testcontainers/testcontainers-go"
+	tcexec "github.com/testcontainers/testcontainers-go/exec"
+	"github.com/testcontainers/testcontainers-go/network"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	"github.com/worldline-go/test/utils"
+)
+
+// clusterTimeout is the wait limit of the cluster state changes.
+const clusterTimeout = 30 * time.Second
+
+// clusterNode is a node of a Redis Cluster.
+type clusterNode struct {
+	container testcontainers.Container
+
+	// address is the host address, announced to the clients
+	address string
+	// ip and port are the address announced to the other nodes, the mapped port on the network gateway
+	ip   string
+	port int
+}
+
+// startCluster starts a Redis Cluster with masters and replicas per master on a shared network.
+//   - Nodes listen on the container ports, docker maps them to random host ports.
+//   - Nodes announce the mapped ports on the network gateway, which reaches the host ports from the nodes.
+//     Clients are redirected to the container host with cluster-announce-hostname.
+func (p *Container) startCluster(t *testing.T, engine Engine, image string, masters, replicas int) error {
+	t.Helper()
+
+	if engine != EngineRedis && engine != EngineValkey {
+		return fmt.Errorf("redis cluster mode is not supported by %s engine", engine)
+	}
+
+	if masters < 3 {
+		return fmt.Errorf("redis cluster needs at least 3 masters, got %d", masters)
+	}
+
+	nw, err := network.New(t.Context(), network.WithLabels(utils.Labels("redis")))
+	if err != nil {
+		return fmt.Errorf("could not create redis network: %w", err)
+	}
+
+	p.network = nw
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+
+	nodes := make([]*clusterNode, masters*(1+replicas))
+	for i := range nodes {
+		req := clusterRequest(engine, image, nw.Name)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			c, err := testcontainers.GenericContainer(t.Context(), testcontainers.GenericContainerRequest{
+				ContainerRequest: req,
+				Started:          true,
+			})
+
+			var node *clusterNode
+			if err == nil {
+				node, err = newClusterNode(t.Context(), c, nw.Name)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			switch {
+			case node != nil:
+				nodes[i] = node
+			case c != nil:
+				nodes[i] = &clusterNode{container: c}
+			}
+
+			if err != nil {
+				errs = append(errs, fmt.Errorf("could not create redis node %d: %w", i, err))
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	for _, node := range nodes {
+		if node != nil {
+			p.containers = append(p.containers, node.container)
+			p.nodes = append(p.nodes, node)
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	create := []string{string(engine) + "-cli", "-p", clusterPort, "--cluster", "create"}
+	for _, node := range p.nodes {
+		create = append(create, net.JoinHostPort(node.ip, strconv.Itoa(node.port)))
+	}
+
+	create = append(create, "--cluster-replicas", strconv.Itoa(replicas), "--cluster-yes")
+
+	if err := execNode(t.Context(), p.nodes[0], create); err != nil {
+		return fmt.Errorf("could not create redis cluster: %w", err)
+	}
+
+	return p.waitCluster(t.Context())
+}
+
+const (
+	// clusterPort and clusterBusPort are the container ports of a node.
+	clusterPort    = "6379"
+	clusterBusPort = "16379"
+)
+
+// clusterRequest returns the container request of a cluster node on the network.
+func clusterRequest(engine Engine, image, networkName string) testcontainers.ContainerRequest {
+	return testcontainers.ContainerRequest{
+		Image:        image,
+		Entrypoint:   utils.StarterEntrypoint(),
+		WaitingFor:   wait.ForLog("Ready to accept connections"),
+		ExposedPorts: []string{clusterPort + "/tcp", clusterBusPort + "/tcp"},
+		Networks:     []string{networkName},
+		LifecycleHooks: []testcontainers.ContainerLifecycleHooks{
+			utils.StarterHook(clusterStarterScript(engine, networkName)),
+		},
+		Labels: utils.Labels("redis"),
+	}
+}
+
+// clusterStarterScript starts a cluster node announcing its mapped ports, known after start.
+func clusterStarterScript(engine Engine, networkName string) func(ctx context.Context, c testcontainers.Container) (string, error) {
+	return func(ctx context.Context, c testcontainers.Container) (string, error) {
+		gateway, err := networkGateway(ctx, c, networkName)
+		if err != nil {
+			return "", err
+		}
+
+		// same host as the node addresses, docker host may differ from DOCKER_HOST with DinD
+		host, err := c.Host(ctx)
+		if err != nil {
+			return "", fmt.Errorf("could not get host: %w", err)
+		}
+
+		port, err := c.MappedPort(ctx, clusterPort+"/tcp")
+		if err != nil {
+			return "", fmt.Errorf("could not get mapped port %s: %w", clusterPort, err)
+		}
+
+		busPort, err := c.MappedPort(ctx, clusterBusPort+"/tcp")
+		if err != nil {
+			return "", fmt.Errorf("could not get mapped port %s: %w", clusterBusPort, err)
+		}
+
+		return "#!/bin/sh\n" +
+			"exec " + string(engine) + "-server" +
+			" --port " + clusterPort +
+			" --protected-mode no --save '' --appendonly no" +
+			" --cluster-enabled yes --cluster-config-file nodes.conf --cluster-node-timeout 5000" +
+			" --cluster-port " + clusterBusPort +
+			" --cluster-announce-ip " + gateway +
+			" --cluster-announce-port " + port.Port() +
+			" --cluster-announce-bus-port " + busPort.Port() +
+			" --cluster-announce-hostname " + host +
+			" --cluster-preferred-endpoint-type hostname\n", nil
+	}
+}
+
+// newClusterNode returns the node of the started container with its announced addresses.
+func newClusterNode(ctx context.Context, c testcontainers.Container, networkName string) (*clusterNode, error) {
+	address, err := utils.MappedAddress(ctx, c, clusterPort+"/tcp")
+	if err != nil {
+		return nil, err
+	}
+
+	_, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, fmt.Errorf("could not split address %s: %w", address, err)
+	}
+
+	gateway, err := networkGateway(ctx, c, networkName)
+	if err != nil {
+		return nil, err
+	}
+
+	node := &clusterNode{
+		container: c,
+		// announced to the clients as the endpoint of the node
+		address: address,
+		ip:      gateway,
+	}
+
+	node.port, err = strconv.Atoi(port)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse port %s: %w", port, err)
+	}
+
+	return node, nil
+}
+
+// networkGateway returns the gateway of the container network, host ports are reachable on it from the containers.
+func networkGateway(ctx context.Context, c testcontainers.Container, networkName string) (string, error) {
+	inspect, err := c.Inspect(ctx)
+	if err != nil {
+		return "", fmt.Errorf("could not inspect redis node: %w", err)
+	}
+
+	endpoint, ok := inspect.NetworkSettings.Networks[networkName]
+	if !ok || endpoint.Gateway == "" {
+		return "", fmt.Errorf("redis node has no gateway on network %s", networkName)
+	}
+
+	return endpoint.Gateway, nil
+}
+
+func execNode(ctx context.Context, node *clusterNode, cmd []string) error {
+	code, reader, err := node.container.Exec(ctx, cmd, tcexec.Multiplexed())
+	if err != nil {
+		return err
+	}
+
+	if code != 0 {
+		output, _ := io.ReadAll(reader)
+
+		return fmt.Errorf("%s exited with %d: %s", cmd[0], code, output)
+	}
+
+	return nil
+}
+
+// waitCluster waits until every node reports the cluster state ok.
+func (p *Container) waitCluster(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, clusterTimeout)
+	defer cancel()
+
+	for _, node := range p.nodes {
+		err := withNode(node.address, func(client *redis.Client) error {
+			for {
+				info, err := client.ClusterInfo(ctx).Result()
+				if err == nil && strings.Contains(info, "cluster_state:ok") {
+					return nil
+				}
+
+				select {
+				case <-ctx.Done():
+					return fmt.Errorf("cluster state is not ok on %s: %w", node.address, errors.Join(ctx.Err(), err))
+				case <-time.After(200 * time.Millisecond):
+				}
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func withNode(address string, fn func(client *redis.Client) error) error {
+	client := redis.NewClient(&redis.Options{Addr: address})
+	defer client.Close()
+
+	return fn(client)
+}
+
+func (p *Container) node(address string) (*clusterNode, error) {
+	for _, node := range p.nodes {
+		if node.address == address {
+			return node, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown redis cluster node %s", address)
+}
+
+// ///////////////////////////////////////////////////////////////////
+
+// Nodes returns the host addresses of the cluster nodes, empty when not in cluster mode.
+func (p *Container) Nodes() []string {
+	nodes := make([]string, 0, len(p.nodes))
+	for _, node := range p.nodes {
+		nodes = append(nodes, node.address)
+	}
+
+	return nodes
+}
+
+// Masters returns the host addresses of the current cluster masters.
+func (p *Container) Masters(t *testing.T) []string {
+	t.Helper()
+
+	var masters []string
+	for _, node := range p.nodes {
+		err := withNode(node.address, func(client *redis.Client) error {
+			role, err := client.Do(t.Context(), "ROLE").Slice()
+			if err != nil {
+				return err
+			}
+
+			if len(role) > 0 && role[0] == "master" {
+				masters = append(masters, node.address)
+			}
+
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("could not get role of %s: %v", node.address, err)
+		}
+	}
+
+	return masters
+}
+
+// SlotOwner returns the host address of the master serving the slot.
+func (p *Container) SlotOwner(t *testing.T, slot int) string {
+	t.Helper()
+
+	owner, err := p.slotOwner(t.Context(), slot)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return owner.address
+}
+
+func (p *Container) slotOwner(ctx context.Context, slot int) (*clusterNode, error) {
+	if len(p.nodes) == 0 {
+		return nil, errors.New("redis is not in cluster mode")
+	}
+
+	var slots []redis.ClusterSlot
+	err := withNode(p.nodes[0].address, func(client *redis.Client) error {
+		var err error
+		slots, err = client.ClusterSlots(ctx).Result()
+
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not get cluster slots: %w", err)
+	}
+
+	for _, s := range slots {
+		if slot >= s.Start && slot <= s.End && len(s.Nodes) > 0 {
+			return p.node(s.Nodes[0].Addr)
+		}
+	}
+
+	return nil, fmt.Errorf("slot %d is not served", slot)
+}
+
+// MigrateSlot moves the slot with its keys to the target master.
+//   - Clients with the old slot map get MOVED redirects, during the migration ASK redirects.
+func (p *Container) MigrateSlot(t *testing.T, slot int, target string) {
+	t.Helper()
+
+	if err := p.migrateSlot(t.Context(), slot, target); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (p *Container) migrateSlot(ctx context.Context, slot int, target string) error {
+	dst, err := p.node(target)
+	if err != nil {
+		return err
+	}
+
+	src, err := p.slotOwner(ctx, slot)
+	if err != nil {
+		return err
+	}
+
+	if src == dst {
+		return nil
+	}
+
+	srcClient := redis.NewClient(&redis.Options{Addr: src.address})
+	defer srcClient.Close()
+
+	dstClient := redis.NewClient(&redis.Options{Addr: dst.address})
+	defer dstClient.Close()
+
+	srcID, err := srcClient.ClusterMyID(ctx).Result()
+	if err != nil {
+		return fmt.Errorf("could not get node id of %s: %w", src.address, err)
+	}
+
+	dstID, err := dstClient.ClusterMyID(ctx).Result()
+	if err != nil {
+		return fmt.Errorf("could not get node id of %s: %w", dst.address, err)
+	}
+
+	if err := dstClient.Do(ctx, "CLUSTER", "SETSLOT", slot, "IMPORTING", srcID).Err(); err != nil {
+		return fmt.Errorf("could not set slot %d importing: %w", slot, err)
+	}
+
+	if err := srcClient.Do(ctx, "CLUSTER", "SETSLOT", slot, "MIGRATING", dstID).Err(); err != nil {
+		return fmt.Errorf("could not set slot %d migrating: %w", slot, err)
+	}
+
+	for {
+		keys, err := srcClient.ClusterGetKeysInSlot(ctx, slot, 100).Result()
+		if err != nil {
+			return fmt.Errorf("could not get keys of slot %d: %w", slot, err)
+		}
+
+		if len(keys) == 0 {
+			break
+		}
+
+		// nodes reach each other on the cluster network
+		args := []any{"MIGRATE", dst.ip, dst.port, "", 0, 5000, "KEYS"}
+		for _, key := range keys {
+			args = append(args, key)
+		}
+
+		if err := srcClient.Do(ctx, args...).Err(); err != nil {
+			return fmt.Errorf("could not migrate keys of slot %d: %w", slot, err)
+		}
+	}
+
+	// owner is set on the destination first, other masters learn it from the cluster bus
+	for _, node := range []*clusterNode{dst, src} {
+		err := withNode(node.address, func(client *redis.Client) error {
+			return client.Do(ctx, "CLUSTER", "SETSLOT", slot, "NODE", dstID).Err()
+		})
+		if err != nil {
+			return fmt.Errorf("could not set slot %d owner on %s: %w", slot, node.address, err)
+		}
+	}
+
+	return nil
+}
+
+// Failover promotes a replica of the master and returns its host address.
+func (p *Container) Failover(t *testing.T, master string) string {
+	t.Helper()
+
+	address, err := p.failover(t.Context(), master)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return address
+}
+
+func (p *Container) failover(ctx context.Context, master string) (string, error) {
+	m, err := p.node(master)
+	if err != nil {
+		return "", err
+	}
+
+	var replica *clusterNode
+	for _, node := range p.nodes {
+		err := withNode(node.address, func(client *redis.Client) error {
+			role, err := client.Do(ctx, "ROLE").Slice()
+			if err != nil {
+				return err
+			}
+
+			// replica role is [slave, master ip, master port, state, offset]
+			if len(role) >= 3 && (role[0] == "slave" || role[0] == "replica") && role[1] == m.ip && fmt.Sprint(role[2]) == strconv.Itoa(m.port) {
+				replica = node
+			}
+
+			return nil
+		})
+		if err != nil {
+			return "", fmt.Errorf("could not get role of %s: %w", node.address, err)
+		}
+
+		if replica != nil {
+			break
+		}
+	}
+
+	if replica == nil {
+		return "", fmt.Errorf("master %s has no replica", master)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, clusterTimeout)
+	defer cancel()
+
+	err = withNode(replica.address, func(client *redis.Client) error {
+		if err := client.ClusterFailover(ctx).Err(); err != nil {
+			return err
+		}
+
+		for {
+			role, err := client.Do(ctx, "ROLE").Slice()
+			if err == nil && len(role) > 0 && role[0] == "master" {
+				return nil
+			}
+
+			select {
+			case <-ctx.Done():
+				return errors.Join(ctx.Err(), err)
+			case <-time.After(200 * time.Millisecond):
+			}
+		}
+	})
+	if err != nil {
+		return "", fmt.Errorf("could not failover %s to %s: %w", master, replica.address, err)
+	}
+
+	if err := p.waitCluster(ctx); err != nil {
+		return "", err
+	}
+
+	return replica.address, nil
+}
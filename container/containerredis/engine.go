@@ -0,0 +1,87 @@
+package containerredis
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	"github.com/worldline-go/test/utils"
+)
+
+// Engine is a Redis compatible server.
+type Engine string
+
+const (
+	EngineDragonfly Engine = "dragonfly"
+	EngineRedis     Engine = "redis"
+	EngineValkey    Engine = "valkey"
+	EngineKeyDB     Engine = "keydb"
+)
+
+// DefaultImages are the images of the engines, TEST_IMAGE_REDIS env overrides them.
+var DefaultImages = map[Engine]string{
+	EngineDragonfly: DefaultRedisImage,
+	EngineRedis:     "docker.io/redis:7.4-alpine",
+	EngineValkey:    "docker.io/valkey/valkey:8.1-alpine",
+	EngineKeyDB:     "docker.io/eqalpha/keydb:v6.3.4", // multi-arch, architecture tags like x86_64_v6.3.4 are single-arch
+}
+
+// detectEngine returns the engine of the image name, images not matching others are Redis.
+func detectEngine(image string) Engine {
+	name := strings.ToLower(image)
+	for _, engine := range []Engine{EngineDragonfly, EngineValkey, EngineKeyDB} {
+		if strings.Contains(name, string(engine)) {
+			return engine
+		}
+	}
+
+	return EngineRedis
+}
+
+// request returns the container request starting the engine.
+//   - Dragonfly runs in emulated cluster mode, others run standalone.
+func (e Engine) request(image string) (testcontainers.ContainerRequest, error) {
+	req := testcontainers.ContainerRequest{
+		Image:        image,
+		ExposedPorts: []string{"6379/tcp"},
+		Labels:       utils.Labels("redis"),
+	}
+
+	switch e {
+	case EngineDragonfly:
+		// cluster slots announce the mapped port, known after start
+		req.Entrypoint = utils.StarterEntrypoint()
+		req.WaitingFor = wait.ForLog("listening on port 6379")
+		req.LifecycleHooks = []testcontainers.ContainerLifecycleHooks{
+			utils.StarterHook(dragonflyStarterScript),
+		}
+	case EngineRedis, EngineValkey, EngineKeyDB:
+		req.Cmd = []string{string(e) + "-server", "--port", "6379", "--protected-mode", "no", "--save", "", "--appendonly", "no"}
+		req.WaitingFor = wait.ForLog("Ready to accept connections")
+	default:
+		return req, fmt.Errorf("unknown redis engine %q", e)
+	}
+
+	return req, nil
+}
+
+// dragonflyStarterScript starts Dragonfly in emulated cluster mode announcing the mapped host address.
+func dragonflyStarterScript(ctx context.Context, c testcontainers.Container) (string, error) {
+	address, err := utils.MappedAddress(ctx, c, "6379/tcp")
+	if err != nil {
+		return "", err
+	}
+
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return "", fmt.Errorf("could not split address %s: %w", address, err)
+	}
+
+	return "#!/bin/sh\n" +
+		"exec entrypoint.sh dragonfly --logtostderr --cluster_mode=emulated" +
+		" --cluster_announce_ip=" + host + " --announce_port=" + port + "\n", nil
+}
@@ -0,0 +1,45 @@
+package containerredis
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/testcontainers/testcontainers-go"
+
+	"github.com/worldline-go/test/utils"
+)
+
+// EnvName is the container name of the testenv command.
+const EnvName = "testenv-redis"
+
+// StartEnv starts the Redis container of the local dev environment, or attaches to the running one.
+//   - Engine and image are selected like in New without options.
+//   - Container is kept running, disable the testcontainers reaper to keep it after the process exits.
+//   - Returns the REDIS_ADDRESS env picked up by New.
+func StartEnv(ctx context.Context) (map[string]string, error) {
+	engine, image := engineImage(option{})
+
+	req, err := engine.request(image)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Name = EnvName
+	req.Labels[utils.LabelEnv] = "true"
+
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+		Reuse:            true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not create redis container: %w", err)
+	}
+
+	address, err := utils.MappedAddress(ctx, container, "6379/tcp")
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]string{"REDIS_ADDRESS": address}, nil
+}
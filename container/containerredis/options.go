@@ -0,0 +1,45 @@
+package containerredis
+
+type Option func(o *option)
+
+type option struct {
+	Engine Engine
+	Memory bool
+
+	ClusterMasters  int
+	ClusterReplicas int
+}
+
+func (o *option) apply(opts ...Option) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// WithMemory runs an in-process miniredis server instead of the container, no Docker needed.
+//   - TEST_REDIS_BACKEND=memory env selects it too.
+//   - Ignored when REDIS_ADDRESS env is set.
+func WithMemory() Option {
+	return func(o *option) {
+		o.Memory = true
+	}
+}
+
+// WithEngine selects the server engine, default is Dragonfly.
+//   - TEST_REDIS_ENGINE env selects it when the option is not set.
+//   - Without both, the engine is detected from the TEST_IMAGE_REDIS image name.
+func WithEngine(engine Engine) Option {
+	return func(o *option) {
+		o.Engine = engine
+	}
+}
+
+// WithCluster starts a real Redis Cluster with masters and replicas per master, like 3 masters with 1 replica.
+//   - Supported by Redis and Valkey engines, default engine is Redis in cluster mode.
+//   - Nodes listen on random host ports, they announce the mapped ports after the start.
+func WithCluster(masters, replicas int) Option {
+	return func(o *option) {
+		o.ClusterMasters = masters
+		o.ClusterReplicas = replicas
+	}
+}
@@ -1,90 +1,178 @@
 package containerredis
 
 import (
-	"net"
 	"os"
+	"strings"
 	"testing"
 
-	"github.com/docker/docker/api/types/container"
-	"github.com/docker/go-connections/nat"
+	"github.com/alicebob/miniredis/v2"
 	"github.com/testcontainers/testcontainers-go"
-	"github.com/testcontainers/testcontainers-go/wait"
+
 	"github.com/worldline-go/test/utils"
+	"github.com/worldline-go/test/utils/redisutils"
 )
 
 var DefaultRedisImage = "docker.dragonflydb.io/dragonflydb/dragonfly:v1.27.1"
 
 type Container struct {
-	container testcontainers.Container
+	containers []testcontainers.Container
+	network    *testcontainers.DockerNetwork
+	memory     *miniredis.Miniredis
+	*redisutils.RedisTest
 
+	engine  Engine
 	address []string
+	nodes   []*clusterNode
+	// shared containers are used by other packages, not terminated in Stop
+	shared bool
 }
 
 func (p *Container) Stop(t *testing.T) {
 	t.Helper()
 
-	if err := p.container.Terminate(t.Context()); err != nil {
-		t.Fatalf("could not stop redis container: %v", err)
+	if p.RedisTest != nil && p.RedisTest.Client != nil {
+		p.RedisTest.Client.Close()
+	}
+
+	if !p.shared {
+		for _, container := range p.containers {
+			if err := container.Terminate(t.Context()); err != nil {
+				t.Fatalf("could not stop redis container: %v", err)
+			}
+		}
+	}
+
+	if p.memory != nil {
+		p.memory.Close()
+	}
+
+	if p.network != nil {
+		if err := p.network.Remove(t.Context()); err != nil {
+			t.Fatalf("could not remove redis network: %v", err)
+		}
+	}
+}
+
+func New(t *testing.T, opts ...Option) *Container {
+	t.Helper()
+
+	o := option{}
+	o.apply(opts...)
+
+	result := &Container{}
+
+	if v := os.Getenv("REDIS_ADDRESS"); v != "" {
+		result.address = strings.Fields(strings.ReplaceAll(v, ",", " "))
+	}
+
+	if len(result.address) == 0 && (o.Memory || os.Getenv("TEST_REDIS_BACKEND") == "memory") {
+		memory, err := miniredis.Run()
+		if err != nil {
+			t.Fatalf("could not start in-memory redis: %v", err)
+		}
+
+		result.memory = memory
+		result.address = []string{memory.Addr()}
 	}
+
+	if len(result.address) == 0 {
+		result.start(t, o)
+	}
+
+	result.RedisTest = redisutils.NewTest(t, result.address)
+
+	return result
 }
 
-func New(t *testing.T) *Container {
+// start starts the engine containers.
+func (p *Container) start(t *testing.T, o option) {
 	t.Helper()
 
-	image := DefaultRedisImage
-	if v := os.Getenv("TEST_IMAGE_REDIS"); v != "" {
-		image = v
+	engine, image := engineImage(o)
+
+	p.engine = engine
+
+	if o.ClusterMasters > 0 {
+		if err := p.startCluster(t, engine, image, o.ClusterMasters, o.ClusterReplicas); err != nil {
+			p.Stop(t)
+			t.Fatal(err)
+		}
+
+		p.address = p.Nodes()
+
+		return
 	}
 
-	announceIP := "localhost"
-	if v := os.Getenv("TESTCONTAINERS_HOST_OVERRIDE"); v != "" {
-		announceIP = v
+	req, err := engine.request(image)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// clusters are not shared, their network is not reusable
+	p.shared = utils.Shared()
+	if p.shared {
+		req.Name = utils.SharedName("redis", image, engine)
 	}
 
 	container, err := testcontainers.GenericContainer(t.Context(), testcontainers.GenericContainerRequest{
-		ContainerRequest: testcontainers.ContainerRequest{
-			Image: image,
-			Cmd: []string{
-				"dragonfly",
-				"--logtostderr",
-				"--cluster_mode=emulated",
-				"--cluster_announce_ip=" + announceIP,
-			},
-			WaitingFor:   wait.ForLog("listening on port 6379"),
-			ExposedPorts: []string{"6379/tcp"},
-			HostConfigModifier: func(hostConfig *container.HostConfig) {
-				hostConfig.PortBindings = nat.PortMap{
-					"6379/tcp": []nat.PortBinding{
-						{
-							HostIP:   "0.0.0.0",
-							HostPort: "6379",
-						},
-					},
-				}
-			},
-			Labels: utils.EnvToLabels(),
-		},
-		Started:      true,
-		ProviderType: 0,
-		Reuse:        false,
+		ContainerRequest: req,
+		Started:          true,
+		ProviderType:     0,
+		Reuse:            p.shared,
 	})
 	if err != nil {
 		t.Fatalf("could not create redis container: %v", err)
 	}
 
-	host, err := container.Host(t.Context())
+	p.containers = append(p.containers, container)
+
+	address, err := utils.MappedAddress(t.Context(), container, "6379/tcp")
 	if err != nil {
-		t.Fatalf("could not get host: %v", err)
+		p.Stop(t)
+		t.Fatal(err)
+	}
+
+	p.address = []string{address}
+}
+
+// engineImage returns the engine and the image of the options and the TEST_REDIS_ENGINE and TEST_IMAGE_REDIS envs.
+func engineImage(o option) (Engine, string) {
+	image := os.Getenv("TEST_IMAGE_REDIS")
+
+	engine := o.Engine
+	if engine == "" {
+		engine = Engine(os.Getenv("TEST_REDIS_ENGINE"))
 	}
 
-	address := net.JoinHostPort(host, "6379")
+	if engine == "" {
+		switch {
+		case image != "":
+			engine = detectEngine(image)
+		case o.ClusterMasters > 0:
+			engine = EngineRedis
+		default:
+			engine = EngineDragonfly
+		}
+	}
 
-	return &Container{
-		container: container,
-		address:   []string{address},
+	if image == "" {
+		image = DefaultImages[engine]
 	}
+
+	return engine, image
 }
 
 func (p *Container) Address() []string {
 	return p.address
 }
+
+// Engine returns the engine of the started server, empty for in-memory and REDIS_ADDRESS servers.
+func (p *Container) Engine() Engine {
+	return p.engine
+}
+
+// Memory returns the in-memory server, nil when not in memory mode.
+//   - Use it to move the time forward with FastForward, TTLs don't expire by themselves.
+func (p *Container) Memory() *miniredis.Miniredis {
+	return p.memory
+}
@@ -1,11 +1,16 @@
 package containerredis_test
 
 import (
+	"os"
+	"strconv"
 	"testing"
+	"time"
 
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 	"github.com/worldline-go/test/container/containerredis"
 	container "github.com/worldline-go/test/container/containerredis"
+	"github.com/worldline-go/test/utils/redisutils"
 )
 
 type RedisSuite struct {
@@ -24,3 +29,130 @@ func TestExampleTestSuiteRedis(t *testing.T) {
 func (s *RedisSuite) TearDownSuite() {
 	s.container.Stop(s.T())
 }
+
+func TestMemory(t *testing.T) {
+	if os.Getenv("REDIS_ADDRESS") != "" {
+		t.Skip("REDIS_ADDRESS env uses an existing server")
+	}
+
+	c := container.New(t, container.WithMemory())
+	defer c.Stop(t)
+
+	require.NotNil(t, c.Memory())
+
+	c.Set(t, "test-memory", "value", time.Minute)
+	c.AssertKey(t, "test-memory", "value")
+
+	// TTLs expire only when the time is moved forward
+	c.Memory().FastForward(2 * time.Minute)
+
+	require.Zero(t, c.Client.Exists(t.Context(), "test-memory").Val())
+}
+
+func TestNamespaceHandles(t *testing.T) {
+	if os.Getenv("REDIS_ADDRESS") != "" {
+		t.Skip("REDIS_ADDRESS env uses an existing server")
+	}
+
+	c := container.New(t, container.WithMemory())
+	// namespaces are deleted in cleanup, before the server is stopped
+	t.Cleanup(func() { c.Stop(t) })
+
+	// two handles on one server, like two suites of a package
+	other := redisutils.NewTest(t, c.Address())
+	t.Cleanup(func() { other.Client.Close() })
+
+	a := c.Namespace(t)
+	b := other.Namespace(t)
+	require.NotEqual(t, a.Prefix, b.Prefix)
+
+	a.Set(t, "key", "a", 0)
+	b.Set(t, "key", "b", 0)
+
+	a.AssertKey(t, "key", "a")
+	b.AssertKey(t, "key", "b")
+}
+
+func TestCluster(t *testing.T) {
+	if os.Getenv("REDIS_ADDRESS") != "" || os.Getenv("TEST_REDIS_BACKEND") == "memory" {
+		t.Skip("cluster needs the redis containers")
+	}
+
+	c := container.New(t, container.WithCluster(3, 0))
+	defer c.Stop(t)
+
+	require.Len(t, c.Masters(t), 3)
+
+	// keys are spread over the slots of all masters
+	owners := map[string]bool{}
+	for i := range 30 {
+		key := "key:" + strconv.Itoa(i)
+		require.NoError(t, c.Client.Set(t.Context(), key, i, 0).Err())
+
+		slot := int(c.Client.ClusterKeySlot(t.Context(), key).Val())
+		owners[c.SlotOwner(t, slot)] = true
+	}
+
+	require.Len(t, owners, 3)
+
+	for i := range 30 {
+		got, err := c.Client.Get(t.Context(), "key:"+strconv.Itoa(i)).Int()
+		require.NoError(t, err)
+		require.Equal(t, i, got)
+	}
+}
+
+func (s *RedisSuite) TestLoadFixtures() {
+	t := s.T()
+
+	s.container.FlushAll(t)
+	s.container.LoadFixtures(t, "testdata/fixtures.yaml")
+
+	s.container.AssertKey(t, "greeting", "hello")
+	s.container.AssertKey(t, "session:1", `{"user":1}`)
+	s.container.AssertTTL(t, "session:1", time.Minute, 5*time.Second)
+	s.container.AssertTTL(t, "greeting", -1, 0)
+	s.container.AssertHash(t, "user:1", map[string]string{"name": "alice", "role": "admin"})
+
+	s.Equal([]string{"a", "b"}, s.container.Client.LRange(t.Context(), "queue", 0, -1).Val())
+	s.ElementsMatch([]string{"x", "y"}, s.container.Client.SMembers(t.Context(), "tags").Val())
+}
+
+func (s *RedisSuite) TestSetGet() {
+	t := s.T()
+
+	type session struct {
+		User int `json:"user"`
+	}
+
+	s.container.Set(t, "test-set", session{User: 2}, 10*time.Second)
+
+	var got session
+	s.container.GetJSON(t, "test-set", &got)
+	s.Equal(2, got.User)
+
+	s.container.AssertTTL(t, "test-set", 10*time.Second, 2*time.Second)
+}
+
+func (s *RedisSuite) TestNamespace() {
+	t := s.T()
+
+	var prefix string
+	t.Run("namespace", func(t *testing.T) {
+		ns := s.container.Namespace(t)
+		prefix = ns.Prefix
+
+		ns.Set(t, "user:1", "alice", 0)
+		ns.Set(t, "user:2", "bob", 0)
+
+		// multi-key commands work in cluster mode, keys share the hash tag
+		s.Equal([]any{"alice", "bob"}, ns.Client.MGet(t.Context(), "user:1", "user:2").Val())
+		s.ElementsMatch([]string{"user:1", "user:2"}, ns.Client.Keys(t.Context(), "user:*").Val())
+
+		s.container.AssertKey(t, prefix+"user:1", "alice")
+	})
+
+	keys, err := s.container.Client.Keys(t.Context(), prefix+"*").Result()
+	s.Require().NoError(err)
+	s.Empty(keys)
+}
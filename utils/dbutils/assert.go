@@ -0,0 +1,252 @@
+package dbutils
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// AssertRowCount checks the number of rows in the table matching the where condition.
+//   - Empty where counts all rows, set the arguments of the where condition with WithArgs.
+//   - Query uses the context of WithAssertContext, default is context.Background, usable in t.Cleanup.
+func (db *DatabaseTest) AssertRowCount(t *testing.T, table, where string, expected int, opts ...OptionAssert) bool {
+	t.Helper()
+
+	opt := apply(opts)
+
+	query := "SELECT COUNT(*) FROM " + trim(table)
+	if strings.TrimSpace(where) != "" {
+		query += " WHERE " + where
+	}
+
+	var count int
+	if err := db.db.executor().QueryRow(opt.Ctx, query, opt.Args...).Scan(&count); err != nil {
+		t.Errorf("could not count rows of %s: %v", table, err)
+
+		return false
+	}
+
+	if count != expected {
+		t.Errorf("row count mismatch for %s: expected %d, actual %d", query, expected, count)
+
+		return false
+	}
+
+	return true
+}
+
+// AssertRows checks the rows returned by the query.
+//   - Only columns in the expected rows are compared, other columns are ignored.
+//   - Rows are compared in order, use WithUnordered to match in any order.
+//   - Integers and floats are compared by value, time.Time with Equal, other values must have the same type.
+//     Numeric and uuid columns are returned as strings by the driver, expect them as strings.
+func (db *DatabaseTest) AssertRows(t *testing.T, query string, expected []map[string]any, opts ...OptionAssert) bool {
+	t.Helper()
+
+	opt := apply(opts)
+
+	result, err := db.db.query(opt.Ctx, query, opt.Args...)
+	if err != nil {
+		t.Errorf("could not assert rows: %v", err)
+
+		return false
+	}
+
+	actual := result.Maps()
+
+	var diff []string
+	if opt.Unordered {
+		diff = diffUnordered(expected, actual)
+	} else {
+		diff = diffOrdered(expected, actual)
+	}
+
+	if len(diff) > 0 {
+		t.Errorf("rows mismatch for query: %s\n%s", query, strings.Join(diff, "\n"))
+
+		return false
+	}
+
+	return true
+}
+
+// ///////////////////////////////////////////////////////////////////////////
+
+func diffOrdered(expected, actual []map[string]any) []string {
+	var diff []string
+	for i := range max(len(expected), len(actual)) {
+		switch {
+		case i >= len(actual):
+			diff = append(diff, fmt.Sprintf("  row %d missing:\n    - %s", i, formatRow(expected[i], expected[i])))
+		case i >= len(expected):
+			diff = append(diff, fmt.Sprintf("  row %d unexpected:\n    + %s", i, formatRow(actual[i], nil)))
+		case !matchRow(expected[i], actual[i]):
+			diff = append(diff, fmt.Sprintf("  row %d:\n    - %s\n    + %s", i, formatRow(expected[i], expected[i]), formatRow(actual[i], expected[i])))
+		}
+	}
+
+	return diff
+}
+
+// diffUnordered matches the expected rows to the actual rows in any order.
+//   - Expected rows may have only some columns and fit several actual rows,
+//     rows are paired with a maximum bipartite matching instead of the first fit.
+func diffUnordered(expected, actual []map[string]any) []string {
+	fits := make([][]bool, len(expected))
+	for e := range expected {
+		fits[e] = make([]bool, len(actual))
+		for a := range actual {
+			fits[e][a] = matchRow(expected[e], actual[a])
+		}
+	}
+
+	// owner is the expected row paired with the actual row, -1 when not paired
+	owner := make([]int, len(actual))
+	for a := range owner {
+		owner[a] = -1
+	}
+
+	// assign pairs the expected row, moving other pairs along an augmenting path
+	var assign func(e int, visited []bool) bool
+	assign = func(e int, visited []bool) bool {
+		for a := range actual {
+			if !fits[e][a] || visited[a] {
+				continue
+			}
+
+			visited[a] = true
+			if owner[a] < 0 || assign(owner[a], visited) {
+				owner[a] = e
+
+				return true
+			}
+		}
+
+		return false
+	}
+
+	paired := make([]bool, len(expected))
+	for e := range expected {
+		paired[e] = assign(e, make([]bool, len(actual)))
+	}
+
+	var diff []string
+	for e, ok := range paired {
+		if !ok {
+			diff = append(diff, "  missing:\n    - "+formatRow(expected[e], expected[e]))
+		}
+	}
+
+	for a, e := range owner {
+		if e < 0 {
+			diff = append(diff, "  unexpected:\n    + "+formatRow(actual[a], nil))
+		}
+	}
+
+	return diff
+}
+
+// matchRow checks the expected columns of the actual row.
+func matchRow(expected, actual map[string]any) bool {
+	for column, e := range expected {
+		a, ok := actual[column]
+		if !ok || !matchValue(e, a) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// matchValue compares the values by type, integers and floats are compared by value.
+func matchValue(expected, actual any) bool {
+	expected, actual = normalizeValue(expected), normalizeValue(actual)
+
+	switch e := expected.(type) {
+	case time.Time:
+		a, ok := actual.(time.Time)
+
+		return ok && e.Equal(a)
+	case int64:
+		if a, ok := actual.(float64); ok {
+			return float64(e) == a
+		}
+	case float64:
+		if a, ok := actual.(int64); ok {
+			return e == float64(a)
+		}
+	}
+
+	// types must match, 1 is not "1"
+	return reflect.DeepEqual(expected, actual)
+}
+
+func normalizeValue(v any) any {
+	switch v := v.(type) {
+	case int:
+		return int64(v)
+	case int8:
+		return int64(v)
+	case int16:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case uint8:
+		return int64(v)
+	case uint16:
+		return int64(v)
+	case uint32:
+		return int64(v)
+	case float32:
+		return float64(v)
+	case []byte:
+		return string(v)
+	default:
+		return v
+	}
+}
+
+// formatRow formats the row with sorted columns.
+//   - With columns map, only those columns are shown and missing ones are marked.
+func formatRow(row, columns map[string]any) string {
+	keys := make([]string, 0, len(row))
+	if columns != nil {
+		for column := range columns {
+			keys = append(keys, column)
+		}
+	} else {
+		for column := range row {
+			keys = append(keys, column)
+		}
+	}
+
+	slices.Sort(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		v, ok := row[key]
+		if !ok {
+			parts = append(parts, key+": <missing>")
+
+			continue
+		}
+
+		parts = append(parts, key+": "+formatValue(v))
+	}
+
+	return "{" + strings.Join(parts, ", ") + "}"
+}
+
+func formatValue(v any) string {
+	switch v := normalizeValue(v).(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return fmt.Sprintf("%q", v)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
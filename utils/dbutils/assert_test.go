@@ -0,0 +1,72 @@
+package dbutils_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/worldline-go/test/utils/dbutils"
+)
+
+func TestDiffUnordered(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected []map[string]any
+		actual   []map[string]any
+		diff     int
+	}{
+		{
+			name:     "partial row before specific row",
+			expected: []map[string]any{{"name": "a"}, {"id": 1, "name": "a"}},
+			actual:   []map[string]any{{"id": 1, "name": "a"}, {"id": 2, "name": "a"}},
+		},
+		{
+			name:     "any order",
+			expected: []map[string]any{{"id": 2}, {"id": 1}},
+			actual:   []map[string]any{{"id": 1, "name": "a"}, {"id": 2, "name": "b"}},
+		},
+		{
+			name:     "missing and unexpected",
+			expected: []map[string]any{{"id": 1}, {"id": 3}},
+			actual:   []map[string]any{{"id": 1}, {"id": 2}},
+			diff:     2,
+		},
+		{
+			name:     "duplicate expectation",
+			expected: []map[string]any{{"name": "a"}, {"name": "a"}},
+			actual:   []map[string]any{{"id": 1, "name": "a"}},
+			diff:     1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Len(t, dbutils.DiffUnordered(tt.expected, tt.actual), tt.diff)
+		})
+	}
+}
+
+func TestMatchValue(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		expected any
+		actual   any
+		match    bool
+	}{
+		{expected: 1, actual: int64(1), match: true},
+		{expected: 1, actual: 1.0, match: true},
+		{expected: 1.5, actual: int64(1), match: false},
+		{expected: "a", actual: []byte("a"), match: true},
+		{expected: now, actual: now.UTC(), match: true},
+		{expected: nil, actual: nil, match: true},
+		{expected: 1, actual: "1", match: false},
+		{expected: "1", actual: int64(1), match: false},
+		{expected: nil, actual: "", match: false},
+	}
+
+	for _, tt := range tests {
+		require.Equal(t, tt.match, dbutils.MatchValue(tt.expected, tt.actual), "%#v == %#v", tt.expected, tt.actual)
+	}
+}
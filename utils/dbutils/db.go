@@ -5,15 +5,23 @@ import (
 	"database/sql"
 	"fmt"
 	"os"
-	"path"
+	"path/filepath"
 	"strconv"
 	"sync/atomic"
 	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
 )
 
 type Database struct {
 	DB *sql.DB
 
+	exec Executor
+	dsn  string
+	// ownDB is set when DB is opened by the constructor, Close closes it
+	ownDB bool
+
 	// schema counter
 	schemaCounter int32
 }
@@ -22,18 +30,81 @@ type DatabaseTest struct {
 	db *Database
 }
 
-func New(db *sql.DB) *Database {
+func New(db *sql.DB, opts ...Option) *Database {
+	opt := apply(opts)
+
 	return &Database{
-		DB: db,
+		DB:   db,
+		exec: SqlExecutor(db),
+		dsn:  opt.DSN,
 	}
 }
 
-func NewTest(t *testing.T, db *sql.DB) *DatabaseTest {
+func NewTest(t *testing.T, db *sql.DB, opts ...Option) *DatabaseTest {
 	t.Helper()
 
 	return &DatabaseTest{
-		db: New(db),
+		db: New(db, opts...),
+	}
+}
+
+// NewPgx returns a Database using the pgx pool.
+//   - Helpers executing queries use the pool directly, others use a *sql.DB opened from the pool.
+//   - Close the Database to close the *sql.DB, the pool is kept.
+//   - DSN defaults to the connection string of the pool.
+func NewPgx(pool *pgxpool.Pool, opts ...Option) *Database {
+	opt := apply(opts)
+	if opt.DSN == "" {
+		opt.DSN = pool.Config().ConnString()
+	}
+
+	return &Database{
+		DB:    stdlib.OpenDBFromPool(pool),
+		exec:  PgxExecutor(pool),
+		dsn:   opt.DSN,
+		ownDB: true,
+	}
+}
+
+// NewTestPgx returns a DatabaseTest using the pgx pool, see NewPgx.
+//   - The *sql.DB opened from the pool is closed in t.Cleanup, the pool is kept.
+func NewTestPgx(t *testing.T, pool *pgxpool.Pool, opts ...Option) *DatabaseTest {
+	t.Helper()
+
+	db := NewPgx(pool, opts...)
+
+	t.Cleanup(func() {
+		if err := db.Close(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	return &DatabaseTest{
+		db: db,
+	}
+}
+
+// Close closes the *sql.DB opened by NewPgx, the pool is kept.
+//   - Handles given to New are owned by the caller and not closed.
+func (db *Database) Close() error {
+	if !db.ownDB {
+		return nil
 	}
+
+	if err := db.DB.Close(); err != nil {
+		return fmt.Errorf("could not close database: %w", err)
+	}
+
+	return nil
+}
+
+// executor returns the executor of the constructor, a Database built as a struct literal uses DB.
+func (db *Database) executor() Executor {
+	if db.exec == nil {
+		return SqlExecutor(db.DB)
+	}
+
+	return db.exec
 }
 
 func (db *DatabaseTest) NameGen(prefix string) string {
@@ -41,9 +112,9 @@ func (db *DatabaseTest) NameGen(prefix string) string {
 }
 
 func (db *Database) NameGen(prefix string) string {
-	atomic.AddInt32(&db.schemaCounter, 1)
+	n := atomic.AddInt32(&db.schemaCounter, 1)
 
-	return prefix + "_" + strconv.Itoa(int(db.schemaCounter))
+	return prefix + "_" + strconv.Itoa(int(n))
 }
 
 func (db *DatabaseTest) SetSchema(t *testing.T, schema string, opts ...OptionContext) {
@@ -67,7 +138,7 @@ func (db *Database) setSchema(t *testing.T, schema string, opts ...OptionContext
 		t.Logf("set schema to %s", schema)
 	}
 
-	_, err := db.DB.ExecContext(opt.Ctx, "SET search_path TO "+schema)
+	err := db.executor().Exec(opt.Ctx, "SET search_path TO "+schema)
 	if err != nil {
 		return fmt.Errorf("could not set schema to %s: %w", schema, err)
 	}
@@ -96,7 +167,7 @@ func (db *Database) createSchema(t *testing.T, schema string, opts ...OptionCont
 		t.Logf("create schema %s", schema)
 	}
 
-	_, err := db.DB.ExecContext(opt.Ctx, "CREATE SCHEMA "+schema)
+	err := db.executor().Exec(opt.Ctx, "CREATE SCHEMA "+schema)
 	if err != nil {
 		return fmt.Errorf("could not create schema %s: %w", schema, err)
 	}
@@ -125,7 +196,7 @@ func (db *Database) dropSchema(t *testing.T, schema string, opts ...OptionContex
 		t.Logf("drop schema %s", schema)
 	}
 
-	_, err := db.DB.ExecContext(opt.Ctx, "DROP SCHEMA IF EXISTS "+schema+" CASCADE")
+	err := db.executor().Exec(opt.Ctx, "DROP SCHEMA IF EXISTS "+schema+" CASCADE")
 	if err != nil {
 		return fmt.Errorf("could not drop schema %s: %w", schema, err)
 	}
@@ -141,26 +212,30 @@ func (db *DatabaseTest) ExecuteFolder(t *testing.T, folder string, opts ...Optio
 	}
 }
 
+// ExecuteFolder executes the files in the folder, nested folders included.
+//   - Files are selected and ordered like ExecuteFolderFS, in natural order and filtered with WithInclude and WithExclude.
 func (db *Database) ExecuteFolder(folder string, opts ...OptionExec) error {
 	return db.executeFolder(nil, folder, opts...)
 }
 
 func (db *Database) executeFolder(t *testing.T, folder string, opts ...OptionExec) error {
-	dirEntry, err := os.ReadDir(folder)
+	if t != nil {
+		t.Helper()
+	}
+
+	opt := apply(opts)
+
+	files, err := listFolderFS(os.DirFS(folder), ".", opt)
 	if err != nil {
 		return fmt.Errorf("could not read folder %s: %w", folder, err)
 	}
 
-	var files []string
-	for _, file := range dirEntry {
-		if file.IsDir() {
-			continue
-		}
-
-		files = append(files, path.Join(folder, file.Name()))
+	// paths of the folder are kept in the logs and errors
+	for i, file := range files {
+		files[i] = filepath.Join(folder, filepath.FromSlash(file))
 	}
 
-	return db.executeFiles(t, files, opts...)
+	return db.execute(t, os.ReadFile, files, opt)
 }
 
 func (db *DatabaseTest) ExecuteFiles(t *testing.T, files []string, opts ...OptionExec) {
@@ -176,7 +251,14 @@ func (db *Database) ExecuteFiles(files []string, opts ...OptionExec) error {
 }
 
 func (db *Database) executeFiles(t *testing.T, files []string, opts ...OptionExec) error {
-	opt := apply(opts)
+	if t != nil {
+		t.Helper()
+	}
+
+	return db.execute(t, os.ReadFile, files, apply(opts))
+}
+
+func (db *Database) execute(t *testing.T, readFile func(string) ([]byte, error), files []string, opt *optionExec) error {
 	if t != nil {
 		t.Helper()
 	}
@@ -186,7 +268,7 @@ func (db *Database) executeFiles(t *testing.T, files []string, opts ...OptionExe
 			t.Logf("execute file %s", file)
 		}
 
-		content, err := os.ReadFile(file)
+		content, err := readFile(file)
 		if err != nil {
 			return fmt.Errorf("could not read file %s: %w", file, err)
 		}
@@ -205,7 +287,7 @@ func (db *Database) executeFiles(t *testing.T, files []string, opts ...OptionExe
 			defer cancel()
 		}
 
-		if _, err = db.DB.ExecContext(ctx, contentStr); err != nil {
+		if err = db.executor().Exec(ctx, contentStr); err != nil {
 			return fmt.Errorf("could not execute file %s: %w", file, err)
 		}
 	}
@@ -0,0 +1,38 @@
+package dbutils_test
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/worldline-go/test/utils/dbutils"
+)
+
+func TestNameGen(t *testing.T) {
+	db := dbutils.NewTest(t, nil)
+
+	var (
+		mu    sync.Mutex
+		names = map[string]bool{}
+	)
+
+	t.Run("parallel", func(t *testing.T) {
+		for i := range 50 {
+			t.Run(strconv.Itoa(i), func(t *testing.T) {
+				t.Parallel()
+
+				name := db.NameGen("schema")
+
+				mu.Lock()
+				defer mu.Unlock()
+
+				require.False(t, names[name], "duplicate name %s", name)
+				names[name] = true
+			})
+		}
+	})
+
+	require.Len(t, names, 50)
+}
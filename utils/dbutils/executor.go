@@ -0,0 +1,71 @@
+package dbutils
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Row is a single row result, implemented by *sql.Row and pgx.Row.
+type Row interface {
+	Scan(dest ...any) error
+}
+
+// Executor is the small interface of a database backend used by the helpers.
+//   - Use SqlExecutor for database/sql and PgxExecutor for pgx backends.
+type Executor interface {
+	Exec(ctx context.Context, query string, args ...any) error
+	QueryRow(ctx context.Context, query string, args ...any) Row
+}
+
+// SqlConn is implemented by *sql.DB, *sql.Conn and *sql.Tx.
+type SqlConn interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// PgxConn is implemented by *pgxpool.Pool, *pgx.Conn and pgx.Tx.
+type PgxConn interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+type sqlExecutor struct {
+	conn SqlConn
+}
+
+// SqlExecutor returns an Executor of a database/sql backend.
+func SqlExecutor(conn SqlConn) Executor {
+	return sqlExecutor{conn: conn}
+}
+
+func (e sqlExecutor) Exec(ctx context.Context, query string, args ...any) error {
+	_, err := e.conn.ExecContext(ctx, query, args...)
+
+	return err
+}
+
+func (e sqlExecutor) QueryRow(ctx context.Context, query string, args ...any) Row {
+	return e.conn.QueryRowContext(ctx, query, args...)
+}
+
+type pgxExecutor struct {
+	conn PgxConn
+}
+
+// PgxExecutor returns an Executor of a pgx backend.
+func PgxExecutor(conn PgxConn) Executor {
+	return pgxExecutor{conn: conn}
+}
+
+func (e pgxExecutor) Exec(ctx context.Context, query string, args ...any) error {
+	_, err := e.conn.Exec(ctx, query, args...)
+
+	return err
+}
+
+func (e pgxExecutor) QueryRow(ctx context.Context, query string, args ...any) Row {
+	return e.conn.QueryRow(ctx, query, args...)
+}
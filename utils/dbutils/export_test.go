@@ -0,0 +1,14 @@
+package dbutils
+
+import "io/fs"
+
+// Exposes the pure helpers to the tests.
+var (
+	DiffUnordered  = diffUnordered
+	MatchValue     = matchValue
+	NaturalCompare = naturalCompare
+)
+
+func ListFolderFS(fsys fs.FS, folder string, opts ...OptionExec) ([]string, error) {
+	return listFolderFS(fsys, folder, apply(opts))
+}
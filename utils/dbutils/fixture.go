@@ -0,0 +1,339 @@
+package dbutils
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+)
+
+// fixtureValueRgx matches "${key}" templates inside fixture string values.
+var fixtureValueRgx = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+func (db *DatabaseTest) LoadFixtures(t *testing.T, files []string, opts ...OptionExec) {
+	t.Helper()
+
+	if err := db.db.loadFixtures(t, os.ReadFile, files, opts...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// LoadFixtures inserts the rows of YAML or JSON fixture files into their tables.
+//   - File content is a map of table name to list of rows, `users: [{id: 1, name: "a"}]`.
+//   - Tables are inserted in foreign key order and their sequences are reset afterward.
+//   - String values support "${key}" templates from WithValues, plus "${now}" and "${uuid}".
+func (db *Database) LoadFixtures(files []string, opts ...OptionExec) error {
+	return db.loadFixtures(nil, os.ReadFile, files, opts...)
+}
+
+func (db *DatabaseTest) LoadFixturesFS(t *testing.T, fsys fs.FS, files []string, opts ...OptionExec) {
+	t.Helper()
+
+	if err := db.db.loadFixtures(t, readFileFS(fsys), files, opts...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// LoadFixturesFS is LoadFixtures reading the files from fsys.
+func (db *Database) LoadFixturesFS(fsys fs.FS, files []string, opts ...OptionExec) error {
+	return db.loadFixtures(nil, readFileFS(fsys), files, opts...)
+}
+
+func (db *Database) loadFixtures(t *testing.T, readFile func(string) ([]byte, error), files []string, opts ...OptionExec) error {
+	if t != nil {
+		t.Helper()
+	}
+
+	opt := apply(opts)
+
+	ctx := opt.Ctx
+	if opt.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opt.Timeout)
+		defer cancel()
+	}
+
+	fixtures := make(map[string][]map[string]any)
+	for _, file := range files {
+		if t != nil {
+			t.Logf("load fixture %s", file)
+		}
+
+		content, err := readFile(file)
+		if err != nil {
+			return fmt.Errorf("could not read file %s: %w", file, err)
+		}
+
+		var fixture map[string][]map[string]any
+		if err := yaml.Unmarshal(content, &fixture); err != nil {
+			return fmt.Errorf("could not parse fixture %s: %w", file, err)
+		}
+
+		for table, rows := range fixture {
+			fixtures[trim(table)] = append(fixtures[trim(table)], rows...)
+		}
+	}
+
+	if len(fixtures) == 0 {
+		return nil
+	}
+
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	schema, err := currentSchema(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	tables, err := fixtureOrder(ctx, tx, schema, fixtures)
+	if err != nil {
+		return err
+	}
+
+	tmpl := fixtureTemplate{values: opt.Values, now: time.Now()}
+	for _, table := range tables {
+		for _, row := range fixtures[table] {
+			if err := insertFixtureRow(ctx, tx, table, row, tmpl); err != nil {
+				return err
+			}
+		}
+
+		if err := resetSequences(ctx, tx, schema, table); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit fixtures: %w", err)
+	}
+
+	return nil
+}
+
+// ///////////////////////////////////////////////////////////////////////////
+
+func currentSchema(ctx context.Context, tx *sql.Tx) (string, error) {
+	var schema string
+	if err := tx.QueryRowContext(ctx, "SELECT current_schema()").Scan(&schema); err != nil {
+		return "", fmt.Errorf("could not get current schema: %w", err)
+	}
+
+	return schema, nil
+}
+
+// qualifyTable returns the table name with schema, unqualified names use the current schema.
+func qualifyTable(schema, table string) string {
+	if strings.Contains(table, ".") {
+		return table
+	}
+
+	return schema + "." + table
+}
+
+// fixtureOrder sorts the fixture tables so referenced tables are inserted first.
+func fixtureOrder(ctx context.Context, tx *sql.Tx, schema string, fixtures map[string][]map[string]any) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT DISTINCT tc.table_schema, tc.table_name, ccu.table_schema, ccu.table_name
+		FROM information_schema.table_constraints tc
+		JOIN information_schema.constraint_column_usage ccu
+			ON tc.constraint_schema = ccu.constraint_schema AND tc.constraint_name = ccu.constraint_name
+		WHERE tc.constraint_type = 'FOREIGN KEY'`)
+	if err != nil {
+		return nil, fmt.Errorf("could not read foreign keys: %w", err)
+	}
+	defer rows.Close()
+
+	// qualified name to fixture table name
+	names := make(map[string]string, len(fixtures))
+	for table := range fixtures {
+		names[qualifyTable(schema, table)] = table
+	}
+
+	dependencies := make(map[string][]string)
+	for rows.Next() {
+		var fromSchema, fromTable, toSchema, toTable string
+		if err := rows.Scan(&fromSchema, &fromTable, &toSchema, &toTable); err != nil {
+			return nil, fmt.Errorf("could not scan foreign key: %w", err)
+		}
+
+		from, okFrom := names[fromSchema+"."+fromTable]
+		to, okTo := names[toSchema+"."+toTable]
+		if !okFrom || !okTo || from == to {
+			continue
+		}
+
+		dependencies[from] = append(dependencies[from], to)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not read foreign keys: %w", err)
+	}
+
+	remaining := make([]string, 0, len(fixtures))
+	for table := range fixtures {
+		remaining = append(remaining, table)
+	}
+
+	slices.Sort(remaining)
+
+	inserted := make(map[string]bool, len(fixtures))
+	ordered := make([]string, 0, len(fixtures))
+	for len(remaining) > 0 {
+		next := remaining[:0]
+		for _, table := range remaining {
+			ready := true
+			for _, dep := range dependencies[table] {
+				if !inserted[dep] {
+					ready = false
+
+					break
+				}
+			}
+
+			if !ready {
+				next = append(next, table)
+
+				continue
+			}
+
+			ordered = append(ordered, table)
+			inserted[table] = true
+		}
+
+		if len(next) == len(remaining) {
+			return nil, fmt.Errorf("could not order fixtures, circular foreign keys between %s", strings.Join(next, ", "))
+		}
+
+		remaining = next
+	}
+
+	return ordered, nil
+}
+
+func insertFixtureRow(ctx context.Context, tx *sql.Tx, table string, row map[string]any, tmpl fixtureTemplate) error {
+	columns := make([]string, 0, len(row))
+	for column := range row {
+		columns = append(columns, column)
+	}
+
+	slices.Sort(columns)
+
+	placeholders := make([]string, 0, len(columns))
+	args := make([]any, 0, len(columns))
+	for i, column := range columns {
+		value, err := tmpl.value(row[column])
+		if err != nil {
+			return fmt.Errorf("could not convert fixture %s.%s: %w", table, column, err)
+		}
+
+		placeholders = append(placeholders, "$"+strconv.Itoa(i+1))
+		args = append(args, value)
+	}
+
+	query := "INSERT INTO " + table + " (" + strings.Join(columns, ", ") + ") VALUES (" + strings.Join(placeholders, ", ") + ")"
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("could not insert fixture into %s: %w", table, err)
+	}
+
+	return nil
+}
+
+type fixtureTemplate struct {
+	values map[string]string
+	now    time.Time
+}
+
+// value converts the decoded value to a query argument.
+//   - Maps and lists are encoded as JSON.
+//   - Strings are expanded with templates.
+func (f fixtureTemplate) value(v any) (any, error) {
+	switch v := v.(type) {
+	case map[string]any, []any:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+
+		return string(b), nil
+	case string:
+		return f.expand(v), nil
+	default:
+		return v, nil
+	}
+}
+
+func (f fixtureTemplate) expand(s string) any {
+	if _, ok := f.values["now"]; !ok && s == "${now}" {
+		return f.now
+	}
+
+	return fixtureValueRgx.ReplaceAllStringFunc(s, func(m string) string {
+		key := m[2 : len(m)-1]
+		if v, ok := f.values[key]; ok {
+			return v
+		}
+
+		switch key {
+		case "now":
+			return f.now.Format(time.RFC3339Nano)
+		case "uuid":
+			return uuid.NewString()
+		}
+
+		return m
+	})
+}
+
+// resetSequences sets the serial and identity sequences of the table to continue after the inserted rows.
+func resetSequences(ctx context.Context, tx *sql.Tx, schema, table string) error {
+	tableSchema, tableName, _ := strings.Cut(qualifyTable(schema, table), ".")
+
+	rows, err := tx.QueryContext(ctx, `SELECT column_name FROM information_schema.columns
+		WHERE table_schema = $1 AND table_name = $2 AND (column_default LIKE 'nextval(%' OR is_identity = 'YES')`,
+		tableSchema, tableName,
+	)
+	if err != nil {
+		return fmt.Errorf("could not read sequences of %s: %w", table, err)
+	}
+
+	var columns []string
+	for rows.Next() {
+		var column string
+		if err := rows.Scan(&column); err != nil {
+			rows.Close()
+
+			return fmt.Errorf("could not scan sequence column of %s: %w", table, err)
+		}
+
+		columns = append(columns, column)
+	}
+
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("could not read sequences of %s: %w", table, err)
+	}
+
+	for _, column := range columns {
+		query := "SELECT setval(pg_get_serial_sequence($1, $2), COALESCE(MAX(" + column + "), 0) + 1, false) FROM " + table
+		if _, err := tx.ExecContext(ctx, query, qualifyTable(schema, table), column); err != nil {
+			return fmt.Errorf("could not reset sequence of %s.%s: %w", table, column, err)
+		}
+	}
+
+	return nil
+}
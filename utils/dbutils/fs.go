@@ -0,0 +1,195 @@
+package dbutils
+
+import (
+	"cmp"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func (db *DatabaseTest) ExecuteFolderFS(t *testing.T, fsys fs.FS, folder string, opts ...OptionExec) {
+	t.Helper()
+
+	if err := db.db.executeFolderFS(t, fsys, folder, opts...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// ExecuteFolderFS executes the files in the folder of fsys, nested folders included.
+//   - Files are executed in natural order of their paths, "2_a.sql" runs before "10_a.sql".
+//   - Filter files with WithInclude and WithExclude glob patterns.
+func (db *Database) ExecuteFolderFS(fsys fs.FS, folder string, opts ...OptionExec) error {
+	return db.executeFolderFS(nil, fsys, folder, opts...)
+}
+
+func (db *Database) executeFolderFS(t *testing.T, fsys fs.FS, folder string, opts ...OptionExec) error {
+	if t != nil {
+		t.Helper()
+	}
+
+	opt := apply(opts)
+
+	files, err := listFolderFS(fsys, folder, opt)
+	if err != nil {
+		return fmt.Errorf("could not read folder %s: %w", folder, err)
+	}
+
+	return db.execute(t, readFileFS(fsys), files, opt)
+}
+
+func (db *DatabaseTest) ExecuteFilesFS(t *testing.T, fsys fs.FS, files []string, opts ...OptionExec) {
+	t.Helper()
+
+	if err := db.db.executeFilesFS(t, fsys, files, opts...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// ExecuteFilesFS executes the files of fsys in the given order.
+func (db *Database) ExecuteFilesFS(fsys fs.FS, files []string, opts ...OptionExec) error {
+	return db.executeFilesFS(nil, fsys, files, opts...)
+}
+
+func (db *Database) executeFilesFS(t *testing.T, fsys fs.FS, files []string, opts ...OptionExec) error {
+	if t != nil {
+		t.Helper()
+	}
+
+	return db.execute(t, readFileFS(fsys), files, apply(opts))
+}
+
+func readFileFS(fsys fs.FS) func(string) ([]byte, error) {
+	return func(name string) ([]byte, error) {
+		return fs.ReadFile(fsys, name)
+	}
+}
+
+// ///////////////////////////////////////////////////////////////////////////
+
+// listFolderFS returns the files of the folder and its nested folders passing the patterns, in natural order.
+func listFolderFS(fsys fs.FS, folder string, opt *optionExec) ([]string, error) {
+	folder = path.Clean(folder)
+
+	var files []string
+	err := fs.WalkDir(fsys, folder, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			return nil
+		}
+
+		rel := file
+		if folder != "." {
+			rel = strings.TrimPrefix(file, folder+"/")
+		}
+
+		ok, err := opt.match(rel)
+		if err != nil {
+			return err
+		}
+
+		if ok {
+			files = append(files, file)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(files, naturalCompare)
+
+	return files, nil
+}
+
+// match reports whether the file passes the include and exclude patterns.
+func (o *optionExec) match(file string) (bool, error) {
+	if len(o.Include) > 0 {
+		ok, err := matchAny(o.Include, file)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+
+	ok, err := matchAny(o.Exclude, file)
+	if err != nil {
+		return false, err
+	}
+
+	return !ok, nil
+}
+
+func matchAny(patterns []string, file string) (bool, error) {
+	for _, pattern := range patterns {
+		name := file
+		if !strings.Contains(pattern, "/") {
+			name = path.Base(file)
+		}
+
+		ok, err := path.Match(pattern, name)
+		if err != nil {
+			return false, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+
+		if ok {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// naturalCompare compares strings with digit sequences compared by their numeric value.
+func naturalCompare(a, b string) int {
+	x, y := a, b
+	for x != "" && y != "" {
+		var cx, cy string
+		cx, x = naturalChunk(x)
+		cy, y = naturalChunk(y)
+
+		if isDigit(cx[0]) && isDigit(cy[0]) {
+			nx, ny := strings.TrimLeft(cx, "0"), strings.TrimLeft(cy, "0")
+			if c := cmp.Compare(len(nx), len(ny)); c != 0 {
+				return c
+			}
+
+			if c := strings.Compare(nx, ny); c != 0 {
+				return c
+			}
+
+			continue
+		}
+
+		if c := strings.Compare(cx, cy); c != 0 {
+			return c
+		}
+	}
+
+	if c := cmp.Compare(len(x), len(y)); c != 0 {
+		return c
+	}
+
+	return strings.Compare(a, b)
+}
+
+// naturalChunk splits the leading digit or non-digit sequence of s.
+func naturalChunk(s string) (string, string) {
+	digit := isDigit(s[0])
+
+	i := 1
+	for i < len(s) && isDigit(s[i]) == digit {
+		i++
+	}
+
+	return s[:i], s[i:]
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
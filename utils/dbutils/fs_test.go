@@ -0,0 +1,70 @@
+package dbutils_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/worldline-go/test/utils/dbutils"
+)
+
+func TestNaturalCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "2_a.sql", b: "10_a.sql", want: -1},
+		{a: "10_a.sql", b: "2_a.sql", want: 1},
+		{a: "002_a.sql", b: "10_a.sql", want: -1},
+		{a: "1_a.sql", b: "1_b.sql", want: -1},
+		{a: "a.sql", b: "a.sql", want: 0},
+		{a: "v1.2.sql", b: "v1.10.sql", want: -1},
+		{a: "1.sql", b: "01.sql", want: 1},
+		{a: "a", b: "a1", want: -1},
+		{a: "dir/2.sql", b: "dir/10.sql", want: -1},
+	}
+
+	for _, tt := range tests {
+		require.Equal(t, tt.want, dbutils.NaturalCompare(tt.a, tt.b), "%s <> %s", tt.a, tt.b)
+	}
+}
+
+func TestListFolder(t *testing.T) {
+	fsys := fstest.MapFS{
+		"sql/10_insert.sql":       {},
+		"sql/2_table.sql":         {},
+		"sql/nested/1_insert.sql": {},
+		"sql/nested/broken.sql":   {},
+		"sql/README.md":           {},
+	}
+
+	tests := []struct {
+		name string
+		opts []dbutils.OptionExec
+		want []string
+	}{
+		{
+			name: "all",
+			want: []string{"sql/2_table.sql", "sql/10_insert.sql", "sql/README.md", "sql/nested/1_insert.sql", "sql/nested/broken.sql"},
+		},
+		{
+			name: "include name",
+			opts: []dbutils.OptionExec{dbutils.WithInclude("*.sql")},
+			want: []string{"sql/2_table.sql", "sql/10_insert.sql", "sql/nested/1_insert.sql", "sql/nested/broken.sql"},
+		},
+		{
+			name: "exclude path",
+			opts: []dbutils.OptionExec{dbutils.WithInclude("*.sql"), dbutils.WithExclude("nested/broken.sql")},
+			want: []string{"sql/2_table.sql", "sql/10_insert.sql", "sql/nested/1_insert.sql"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := dbutils.ListFolderFS(fsys, "sql", tt.opts...)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, files)
+		})
+	}
+}
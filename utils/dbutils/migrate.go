@@ -0,0 +1,585 @@
+package dbutils
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// Migration is a versioned migration loaded from a folder.
+type Migration struct {
+	Version int64
+	Name    string
+
+	Up   string
+	Down string
+
+	// HasDown is true when a down migration exists, even if it is empty.
+	HasDown bool
+	// NoTransaction runs the migration outside of a transaction, set with "-- +goose NO TRANSACTION".
+	NoTransaction bool
+}
+
+// MigrateFormat is the layout of the migration table, compatible with the tool of the same name.
+type MigrateFormat string
+
+const (
+	// MigrateGolangMigrate records the current version in "schema_migrations" (version, dirty).
+	MigrateGolangMigrate MigrateFormat = "golang-migrate"
+	// MigrateGoose records the applied versions in "goose_db_version" (version_id, is_applied).
+	MigrateGoose MigrateFormat = "goose"
+)
+
+// migrationFileRgx matches "001_name.up.sql", "001_name.down.sql" and goose style "001_name.sql".
+var migrationFileRgx = regexp.MustCompile(`^(\d+)_(.+?)(?:\.(up|down))?\.sql$`)
+
+// LoadMigrations reads the migrations in the folder sorted by version.
+//   - golang-migrate layout: NNN_name.up.sql and NNN_name.down.sql
+//   - goose layout: NNN_name.sql with "-- +goose Up" and "-- +goose Down" sections
+//   - Other files are ignored.
+func LoadMigrations(folder string) ([]Migration, error) {
+	migrations, _, err := loadMigrations(os.DirFS(folder), ".")
+
+	return migrations, err
+}
+
+// loadMigrations returns the migrations and their format, goose when all files have the goose layout.
+func loadMigrations(fsys fs.FS, dir string) ([]Migration, MigrateFormat, error) {
+	dirEntry, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, "", fmt.Errorf("could not read migrations folder %s: %w", dir, err)
+	}
+
+	format := MigrateGolangMigrate
+	goose := 0
+
+	migrations := make(map[int64]*Migration)
+	// up content can be empty, the seen versions catch a second up file
+	hasUp := make(map[int64]bool)
+	for _, file := range dirEntry {
+		if file.IsDir() {
+			continue
+		}
+
+		match := migrationFileRgx.FindStringSubmatch(file.Name())
+		if match == nil {
+			continue
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, "", fmt.Errorf("could not parse migration version %s: %w", file.Name(), err)
+		}
+
+		content, err := fs.ReadFile(fsys, path.Join(dir, file.Name()))
+		if err != nil {
+			return nil, "", fmt.Errorf("could not read migration %s: %w", file.Name(), err)
+		}
+
+		m, ok := migrations[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			migrations[version] = m
+		} else if m.Name != match[2] {
+			return nil, "", fmt.Errorf("duplicate migration version %d: %s and %s", version, m.Name, match[2])
+		}
+
+		switch match[3] {
+		case "up":
+			if hasUp[version] {
+				return nil, "", fmt.Errorf("duplicate up migration version %d", version)
+			}
+
+			m.Up = string(content)
+			hasUp[version] = true
+		case "down":
+			if m.HasDown {
+				return nil, "", fmt.Errorf("duplicate down migration version %d", version)
+			}
+
+			m.Down = string(content)
+			m.HasDown = true
+		default:
+			if hasUp[version] || m.HasDown {
+				return nil, "", fmt.Errorf("duplicate migration version %d", version)
+			}
+
+			parseGoose(m, string(content))
+			hasUp[version] = true
+
+			goose++
+		}
+	}
+
+	if goose > 0 && goose == len(migrations) {
+		format = MigrateGoose
+	}
+
+	result := make([]Migration, 0, len(migrations))
+	for _, m := range migrations {
+		result = append(result, *m)
+	}
+
+	slices.SortFunc(result, func(a, b Migration) int {
+		return cmp.Compare(a.Version, b.Version)
+	})
+
+	return result, format, nil
+}
+
+// parseGoose splits the goose annotated content to up and down sections.
+//   - Content without annotations is used as up migration.
+func parseGoose(m *Migration, content string) {
+	if !strings.Contains(content, "-- +goose") {
+		m.Up = content
+
+		return
+	}
+
+	var up, down strings.Builder
+
+	section := &up
+	for line := range strings.Lines(content) {
+		annotation, ok := strings.CutPrefix(strings.TrimSpace(line), "-- +goose")
+		if !ok {
+			section.WriteString(line)
+
+			continue
+		}
+
+		switch strings.ToUpper(strings.TrimSpace(annotation)) {
+		case "UP":
+			section = &up
+		case "DOWN":
+			section = &down
+			m.HasDown = true
+		case "NO TRANSACTION":
+			m.NoTransaction = true
+		}
+	}
+
+	m.Up = up.String()
+	m.Down = down.String()
+}
+
+// ///////////////////////////////////////////////////////////////////////////
+
+func (db *DatabaseTest) Migrate(t *testing.T, folder string, opts ...OptionMigrate) {
+	t.Helper()
+
+	if err := db.db.migrate(t, os.DirFS(folder), ".", math.MaxInt64, opts...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Migrate applies all migrations in the folder which are not applied yet.
+func (db *Database) Migrate(folder string, opts ...OptionMigrate) error {
+	return db.migrate(nil, os.DirFS(folder), ".", math.MaxInt64, opts...)
+}
+
+func (db *DatabaseTest) MigrateTo(t *testing.T, folder string, version int64, opts ...OptionMigrate) {
+	t.Helper()
+
+	if err := db.db.migrate(t, os.DirFS(folder), ".", version, opts...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// MigrateTo migrates up or down to the version.
+//   - Migrations greater than the version are rolled back.
+func (db *Database) MigrateTo(folder string, version int64, opts ...OptionMigrate) error {
+	return db.migrate(nil, os.DirFS(folder), ".", version, opts...)
+}
+
+func (db *DatabaseTest) Rollback(t *testing.T, folder string, opts ...OptionMigrate) {
+	t.Helper()
+
+	if err := db.db.migrate(t, os.DirFS(folder), ".", 0, opts...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Rollback rolls back all applied migrations in the folder.
+func (db *Database) Rollback(folder string, opts ...OptionMigrate) error {
+	return db.migrate(nil, os.DirFS(folder), ".", 0, opts...)
+}
+
+func (db *DatabaseTest) MigrateVerify(t *testing.T, folder string, opts ...OptionMigrate) {
+	t.Helper()
+
+	if err := db.db.migrateVerify(t, os.DirFS(folder), ".", opts...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// MigrateVerify checks the down migrations by running up, down and up again for each version.
+//   - Leaves the database migrated to the latest version.
+func (db *Database) MigrateVerify(folder string, opts ...OptionMigrate) error {
+	return db.migrateVerify(nil, os.DirFS(folder), ".", opts...)
+}
+
+func (db *DatabaseTest) MigrationVersion(t *testing.T, opts ...OptionMigrate) int64 {
+	t.Helper()
+
+	version, err := db.db.MigrationVersion(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return version
+}
+
+// MigrationVersion returns the latest applied migration version, 0 if nothing is applied.
+//   - Format is golang-migrate, or goose when only the goose table exists, set it with WithMigrateFormat.
+func (db *Database) MigrationVersion(opts ...OptionMigrate) (int64, error) {
+	opt := apply(opts)
+
+	format := opt.Format
+	if format == "" {
+		var err error
+		if format, err = db.detectFormat(opt); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := db.migrationTable(opt, format); err != nil {
+		return 0, err
+	}
+
+	applied, err := db.appliedVersions(nil, opt, format)
+	if err != nil {
+		return 0, err
+	}
+
+	var version int64
+	for v := range applied {
+		version = max(version, v)
+	}
+
+	return version, nil
+}
+
+func (db *Database) migrate(t *testing.T, fsys fs.FS, dir string, version int64, opts ...OptionMigrate) error {
+	if t != nil {
+		t.Helper()
+	}
+
+	opt := apply(opts)
+
+	migrations, format, err := loadMigrations(fsys, dir)
+	if err != nil {
+		return err
+	}
+
+	return db.migrateTo(t, migrations, cmp.Or(opt.Format, format), version, opt)
+}
+
+func (db *Database) migrateVerify(t *testing.T, fsys fs.FS, dir string, opts ...OptionMigrate) error {
+	if t != nil {
+		t.Helper()
+	}
+
+	opt := apply(opts)
+
+	migrations, format, err := loadMigrations(fsys, dir)
+	if err != nil {
+		return err
+	}
+
+	format = cmp.Or(opt.Format, format)
+
+	for i, m := range migrations {
+		previous := int64(0)
+		if i > 0 {
+			previous = migrations[i-1].Version
+		}
+
+		if err := db.migrateTo(t, migrations, format, m.Version, opt); err != nil {
+			return err
+		}
+
+		if err := db.migrateTo(t, migrations, format, previous, opt); err != nil {
+			return fmt.Errorf("verify down migration %d: %w", m.Version, err)
+		}
+
+		if err := db.migrateTo(t, migrations, format, m.Version, opt); err != nil {
+			return fmt.Errorf("verify up migration %d after down: %w", m.Version, err)
+		}
+	}
+
+	return nil
+}
+
+func (db *Database) migrateTo(t *testing.T, migrations []Migration, format MigrateFormat, version int64, opt *optionMigrate) error {
+	if t != nil {
+		t.Helper()
+	}
+
+	if err := db.migrationTable(opt, format); err != nil {
+		return err
+	}
+
+	applied, err := db.appliedVersions(migrations, opt, format)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if m.Version > version || applied[m.Version] {
+			continue
+		}
+
+		if t != nil {
+			t.Logf("migrate up %d_%s", m.Version, m.Name)
+		}
+
+		if err := db.applyMigration(m, true, 0, opt, format); err != nil {
+			return err
+		}
+	}
+
+	for i, m := range slices.Backward(migrations) {
+		if m.Version <= version || !applied[m.Version] {
+			continue
+		}
+
+		if !m.HasDown {
+			return fmt.Errorf("could not rollback migration %d_%s: down migration not found", m.Version, m.Name)
+		}
+
+		if t != nil {
+			t.Logf("migrate down %d_%s", m.Version, m.Name)
+		}
+
+		previous := int64(0)
+		if i > 0 {
+			previous = migrations[i-1].Version
+		}
+
+		if err := db.applyMigration(m, false, previous, opt, format); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// detectFormat returns goose when only the goose table exists, otherwise golang-migrate.
+func (db *Database) detectFormat(opt *optionMigrate) (MigrateFormat, error) {
+	if opt.Table != "" {
+		return MigrateGolangMigrate, nil
+	}
+
+	var goose, golangMigrate bool
+	if err := db.DB.QueryRowContext(opt.Ctx, "SELECT to_regclass($1) IS NOT NULL, to_regclass($2) IS NOT NULL",
+		opt.table(MigrateGoose), opt.table(MigrateGolangMigrate),
+	).Scan(&goose, &golangMigrate); err != nil {
+		return "", fmt.Errorf("could not detect migration table: %w", err)
+	}
+
+	if goose && !golangMigrate {
+		return MigrateGoose, nil
+	}
+
+	return MigrateGolangMigrate, nil
+}
+
+// migrationTable creates the migration table with the layout of the tool.
+func (db *Database) migrationTable(opt *optionMigrate, format MigrateFormat) error {
+	table := opt.table(format)
+
+	var err error
+	switch format {
+	case MigrateGoose:
+		_, err = db.DB.ExecContext(opt.Ctx, "CREATE TABLE IF NOT EXISTS "+table+` (
+			id SERIAL PRIMARY KEY,
+			version_id BIGINT NOT NULL,
+			is_applied BOOLEAN NOT NULL,
+			tstamp TIMESTAMP DEFAULT NOW()
+		)`)
+		if err == nil {
+			// goose starts the table with version 0
+			_, err = db.DB.ExecContext(opt.Ctx, "INSERT INTO "+table+" (version_id, is_applied) SELECT 0, TRUE WHERE NOT EXISTS (SELECT 1 FROM "+table+")")
+		}
+	case MigrateGolangMigrate:
+		_, err = db.DB.ExecContext(opt.Ctx, "CREATE TABLE IF NOT EXISTS "+table+` (
+			version BIGINT NOT NULL PRIMARY KEY,
+			dirty BOOLEAN NOT NULL
+		)`)
+	default:
+		return fmt.Errorf("unknown migration format %q", format)
+	}
+
+	if err != nil {
+		return fmt.Errorf("could not create migration table %s: %w", table, err)
+	}
+
+	return nil
+}
+
+// appliedVersions returns the applied versions of the migration table.
+//   - golang-migrate records only the current version, migrations up to it are applied.
+func (db *Database) appliedVersions(migrations []Migration, opt *optionMigrate, format MigrateFormat) (map[int64]bool, error) {
+	table := opt.table(format)
+
+	applied := make(map[int64]bool)
+
+	if format == MigrateGolangMigrate {
+		var (
+			version int64
+			dirty   bool
+		)
+
+		err := db.DB.QueryRowContext(opt.Ctx, "SELECT version, dirty FROM "+table+" LIMIT 1").Scan(&version, &dirty)
+		if errors.Is(err, sql.ErrNoRows) {
+			return applied, nil
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("could not read migration table %s: %w", table, err)
+		}
+
+		if dirty {
+			return nil, fmt.Errorf("migration table %s is dirty at version %d, fix the database and the version", table, version)
+		}
+
+		applied[version] = true
+		for _, m := range migrations {
+			if m.Version <= version {
+				applied[m.Version] = true
+			}
+		}
+
+		return applied, nil
+	}
+
+	// goose keeps the history, the latest row of a version decides
+	rows, err := db.DB.QueryContext(opt.Ctx, "SELECT version_id, is_applied FROM "+table+" ORDER BY id DESC")
+	if err != nil {
+		return nil, fmt.Errorf("could not read migration table %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	seen := make(map[int64]bool)
+	for rows.Next() {
+		var (
+			version   int64
+			isApplied bool
+		)
+
+		if err := rows.Scan(&version, &isApplied); err != nil {
+			return nil, fmt.Errorf("could not scan migration version: %w", err)
+		}
+
+		if seen[version] || version == 0 {
+			continue
+		}
+
+		seen[version] = true
+		if isApplied {
+			applied[version] = true
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not read migration table %s: %w", table, err)
+	}
+
+	return applied, nil
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// applyMigration runs the migration and records it, previous is the version after a down migration.
+func (db *Database) applyMigration(m Migration, up bool, previous int64, opt *optionMigrate, format MigrateFormat) error {
+	table := opt.table(format)
+
+	direction, query := "up", m.Up
+	if !up {
+		direction, query = "down", m.Down
+	}
+
+	if len(opt.Values) > 0 {
+		query = os.Expand(query, func(key string) string {
+			return opt.Values[key]
+		})
+	}
+
+	record := func(ex execer) error {
+		switch {
+		case format == MigrateGoose && up:
+			_, err := ex.ExecContext(opt.Ctx, "INSERT INTO "+table+" (version_id, is_applied) VALUES ($1, TRUE)", m.Version)
+
+			return err
+		case format == MigrateGoose:
+			_, err := ex.ExecContext(opt.Ctx, "DELETE FROM "+table+" WHERE version_id = $1", m.Version)
+
+			return err
+		}
+
+		if _, err := ex.ExecContext(opt.Ctx, "DELETE FROM "+table); err != nil {
+			return err
+		}
+
+		version := m.Version
+		if !up {
+			version = previous
+		}
+
+		if version == 0 {
+			return nil
+		}
+
+		_, err := ex.ExecContext(opt.Ctx, "INSERT INTO "+table+" (version, dirty) VALUES ($1, FALSE)", version)
+
+		return err
+	}
+
+	run := func(ex execer) error {
+		if strings.TrimSpace(query) != "" {
+			if _, err := ex.ExecContext(opt.Ctx, query); err != nil {
+				return fmt.Errorf("could not migrate %s %d_%s: %w", direction, m.Version, m.Name, err)
+			}
+		}
+
+		if err := record(ex); err != nil {
+			return fmt.Errorf("could not record migration %s %d_%s: %w", direction, m.Version, m.Name, err)
+		}
+
+		return nil
+	}
+
+	if m.NoTransaction {
+		return run(db.DB)
+	}
+
+	tx, err := db.DB.BeginTx(opt.Ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %w", err)
+	}
+
+	if err := run(tx); err != nil {
+		_ = tx.Rollback()
+
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit migration %s %d_%s: %w", direction, m.Version, m.Name, err)
+	}
+
+	return nil
+}
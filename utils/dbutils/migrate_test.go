@@ -0,0 +1,75 @@
+package dbutils_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/worldline-go/test/utils/dbutils"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		err   string
+	}{
+		{
+			name: "golang-migrate",
+			files: map[string]string{
+				"001_users.up.sql":   "CREATE TABLE users (id int);",
+				"001_users.down.sql": "DROP TABLE users;",
+				"002_email.up.sql":   "",
+			},
+		},
+		{
+			name: "second up after empty up",
+			files: map[string]string{
+				"001_users.up.sql": "",
+				"1_users.up.sql":   "CREATE TABLE users (id int);",
+			},
+			err: "duplicate up migration version 1",
+		},
+		{
+			name: "goose file with up",
+			files: map[string]string{
+				"001_users.sql":    "-- +goose Up\n",
+				"001_users.up.sql": "CREATE TABLE users (id int);",
+			},
+			err: "duplicate",
+		},
+		{
+			name: "name mismatch",
+			files: map[string]string{
+				"001_users.up.sql":    "",
+				"001_accounts.up.sql": "",
+			},
+			err: "duplicate migration version 1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+			}
+
+			migrations, err := dbutils.LoadMigrations(dir)
+			if tt.err != "" {
+				require.ErrorContains(t, err, tt.err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Len(t, migrations, 2)
+			require.Equal(t, int64(1), migrations[0].Version)
+			require.True(t, migrations[0].HasDown)
+			require.Equal(t, int64(2), migrations[1].Version)
+			require.Empty(t, migrations[1].Up)
+		})
+	}
+}
@@ -6,8 +6,11 @@ import (
 )
 
 type (
+	Option        func(o *option)
 	OptionExec    func(o *optionExec)
 	OptionContext func(o *optionContext)
+	OptionMigrate func(o *optionMigrate)
+	OptionAssert  func(o *optionAssert)
 )
 
 // ///////////////////////////////////////////////////////////////////////////
@@ -29,6 +32,21 @@ func apply[T any, O ~func(*T)](opts []O) *T {
 	return opt
 }
 
+// ///////////////////////////////////////////////////////////////////////////
+// funcs of option
+
+type option struct {
+	DSN string
+}
+
+// WithDSN sets the connection string of the database.
+//   - Required to open new connections like in IsolatedSchema.
+func WithDSN(dsn string) Option {
+	return func(o *option) {
+		o.DSN = dsn
+	}
+}
+
 // ///////////////////////////////////////////////////////////////////////////
 // funcs of optionExec
 
@@ -36,6 +54,9 @@ type optionExec struct {
 	Values  map[string]string
 	Timeout time.Duration
 	Ctx     context.Context
+
+	Include []string
+	Exclude []string
 }
 
 func (o *optionExec) Default() {
@@ -58,6 +79,22 @@ func WithTimeout(timeout time.Duration) OptionExec {
 	}
 }
 
+// WithInclude sets glob patterns of the files to execute in ExecuteFolder and ExecuteFolderFS, default is all files.
+//   - Pattern without "/" matches the file name, otherwise the path relative to the folder.
+func WithInclude(patterns ...string) OptionExec {
+	return func(o *optionExec) {
+		o.Include = append(o.Include, patterns...)
+	}
+}
+
+// WithExclude sets glob patterns of the files to skip in ExecuteFolder and ExecuteFolderFS.
+//   - Pattern without "/" matches the file name, otherwise the path relative to the folder.
+func WithExclude(patterns ...string) OptionExec {
+	return func(o *optionExec) {
+		o.Exclude = append(o.Exclude, patterns...)
+	}
+}
+
 // WithContext sets the context for the file execution.
 func WithExecContext(ctx context.Context) OptionExec {
 	return func(o *optionExec) {
@@ -84,3 +121,120 @@ func WithContext(ctx context.Context) OptionContext {
 		o.Ctx = ctx
 	}
 }
+
+// ///////////////////////////////////////////////////////////////////////////
+// funcs of optionMigrate
+
+type optionMigrate struct {
+	Table  string
+	Format MigrateFormat
+	Values map[string]string
+	Ctx    context.Context
+}
+
+func (o *optionMigrate) Default() {
+	if o.Ctx == nil {
+		o.Ctx = context.Background()
+	}
+}
+
+// table returns the table of the option, default is the table of the format.
+func (o *optionMigrate) table(format MigrateFormat) string {
+	if o.Table != "" {
+		return trim(o.Table)
+	}
+
+	if format == MigrateGoose {
+		return "goose_db_version"
+	}
+
+	return "schema_migrations"
+}
+
+// WithMigrateTable sets the table to record applied versions.
+//   - Default is "schema_migrations" for golang-migrate and "goose_db_version" for goose.
+func WithMigrateTable(table string) OptionMigrate {
+	return func(o *optionMigrate) {
+		o.Table = table
+	}
+}
+
+// WithMigrateFormat sets the layout of the migration table, default is detected from the migration files.
+//   - MigrationVersion without the format uses goose when only its table exists.
+func WithMigrateFormat(format MigrateFormat) OptionMigrate {
+	return func(o *optionMigrate) {
+		o.Format = format
+	}
+}
+
+// WithMigrateValues sets os.Expand values inside the migration content.
+func WithMigrateValues(values map[string]string) OptionMigrate {
+	return func(o *optionMigrate) {
+		o.Values = values
+	}
+}
+
+// WithMigrateContext sets the context for the migration.
+func WithMigrateContext(ctx context.Context) OptionMigrate {
+	return func(o *optionMigrate) {
+		o.Ctx = ctx
+	}
+}
+
+// ///////////////////////////////////////////////////////////////////////////
+// funcs of optionAssert
+
+type optionAssert struct {
+	Args      []any
+	Unordered bool
+	Ctx       context.Context
+
+	// Mask and SnapshotDir are used by MatchSnapshot
+	Mask        []string
+	SnapshotDir string
+}
+
+func (o *optionAssert) Default() {
+	if o.Ctx == nil {
+		o.Ctx = context.Background()
+	}
+
+	if o.SnapshotDir == "" {
+		o.SnapshotDir = "testdata/snapshots"
+	}
+}
+
+// WithArgs sets the query arguments.
+func WithArgs(args ...any) OptionAssert {
+	return func(o *optionAssert) {
+		o.Args = args
+	}
+}
+
+// WithUnordered matches the rows in any order.
+func WithUnordered() OptionAssert {
+	return func(o *optionAssert) {
+		o.Unordered = true
+	}
+}
+
+// WithAssertContext sets the context for the query.
+func WithAssertContext(ctx context.Context) OptionAssert {
+	return func(o *optionAssert) {
+		o.Ctx = ctx
+	}
+}
+
+// WithMask replaces the values of the columns in the snapshot, use for volatile columns like timestamps.
+func WithMask(columns ...string) OptionAssert {
+	return func(o *optionAssert) {
+		o.Mask = append(o.Mask, columns...)
+	}
+}
+
+// WithSnapshotDir sets the folder of the snapshot files, default is "testdata/snapshots".
+func WithSnapshotDir(dir string) OptionAssert {
+	return func(o *optionAssert) {
+		o.SnapshotDir = dir
+	}
+}
@@ -0,0 +1,71 @@
+package dbutils
+
+import (
+	"context"
+	"fmt"
+)
+
+// queryResult holds the scanned result of a query.
+type queryResult struct {
+	Columns []string
+	Types   []string
+	Rows    [][]any
+}
+
+func (db *Database) query(ctx context.Context, query string, args ...any) (*queryResult, error) {
+	rows, err := db.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not query: %w", err)
+	}
+	defer rows.Close()
+
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, fmt.Errorf("could not get column types: %w", err)
+	}
+
+	result := &queryResult{
+		Columns: make([]string, 0, len(columnTypes)),
+		Types:   make([]string, 0, len(columnTypes)),
+	}
+
+	for _, columnType := range columnTypes {
+		result.Columns = append(result.Columns, columnType.Name())
+		result.Types = append(result.Types, columnType.DatabaseTypeName())
+	}
+
+	for rows.Next() {
+		values := make([]any, len(columnTypes))
+		pointers := make([]any, len(columnTypes))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, fmt.Errorf("could not scan row: %w", err)
+		}
+
+		result.Rows = append(result.Rows, values)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not read rows: %w", err)
+	}
+
+	return result, nil
+}
+
+// Maps returns the rows as column name to value maps.
+func (r *queryResult) Maps() []map[string]any {
+	maps := make([]map[string]any, 0, len(r.Rows))
+	for _, row := range r.Rows {
+		m := make(map[string]any, len(r.Columns))
+		for i, column := range r.Columns {
+			m[column] = row[i]
+		}
+
+		maps = append(maps, m)
+	}
+
+	return maps
+}
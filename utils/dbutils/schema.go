@@ -0,0 +1,167 @@
+package dbutils
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// Schema is a database handle scoped to its own schema.
+// Every pooled connection sets the search_path to the schema when it is opened.
+type Schema struct {
+	*Database
+
+	Name string
+
+	parent *Database
+}
+
+type SchemaTest struct {
+	*DatabaseTest
+
+	Name string
+
+	sql *sql.DB
+}
+
+func (s *SchemaTest) Sql() *sql.DB {
+	return s.sql
+}
+
+// IsolatedSchema creates a new schema with the prefix and returns a handle using it.
+//   - Schema is dropped in t.Cleanup.
+//   - Database must be created with the WithDSN option.
+func (db *DatabaseTest) IsolatedSchema(t *testing.T, prefix string, opts ...OptionContext) *SchemaTest {
+	t.Helper()
+
+	schema, err := db.db.isolatedSchema(t, prefix, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		if err := schema.close(t); err != nil {
+			t.Error(err)
+		}
+	})
+
+	return &SchemaTest{
+		DatabaseTest: &DatabaseTest{db: schema.Database},
+		Name:         schema.Name,
+		sql:          schema.DB,
+	}
+}
+
+// IsolatedSchema creates a new schema with the prefix and returns a handle using it.
+//   - Close the schema to drop it.
+//   - Database must be created with the WithDSN option.
+func (db *Database) IsolatedSchema(prefix string, opts ...OptionContext) (*Schema, error) {
+	return db.isolatedSchema(nil, prefix, opts...)
+}
+
+func (db *Database) isolatedSchema(t *testing.T, prefix string, opts ...OptionContext) (*Schema, error) {
+	if t != nil {
+		t.Helper()
+	}
+
+	if db.dsn == "" {
+		return nil, errors.New("could not create isolated schema: database dsn is not set")
+	}
+
+	name := db.NameGen(trim(prefix))
+
+	connector, err := newSchemaConnector(db.DB.Driver(), db.dsn, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.createSchema(t, name, opts...); err != nil {
+		return nil, err
+	}
+
+	return &Schema{
+		Database: New(sql.OpenDB(connector), WithDSN(db.dsn)),
+		Name:     name,
+		parent:   db,
+	}, nil
+}
+
+// Close closes the connections of the schema and drops it.
+func (s *Schema) Close(opts ...OptionContext) error {
+	return s.close(nil, opts...)
+}
+
+func (s *Schema) close(t *testing.T, opts ...OptionContext) error {
+	if t != nil {
+		t.Helper()
+	}
+
+	if err := s.DB.Close(); err != nil {
+		return fmt.Errorf("could not close schema %s connections: %w", s.Name, err)
+	}
+
+	return s.parent.dropSchema(t, s.Name, opts...)
+}
+
+// ///////////////////////////////////////////////////////////////////////////
+
+type schemaConnector struct {
+	driver.Connector
+
+	schema string
+}
+
+func newSchemaConnector(d driver.Driver, dsn, schema string) (*schemaConnector, error) {
+	var connector driver.Connector = dsnConnector{driver: d, dsn: dsn}
+	if dc, ok := d.(driver.DriverContext); ok {
+		c, err := dc.OpenConnector(dsn)
+		if err != nil {
+			return nil, fmt.Errorf("could not open connector: %w", err)
+		}
+
+		connector = c
+	}
+
+	return &schemaConnector{
+		Connector: connector,
+		schema:    schema,
+	}, nil
+}
+
+func (c *schemaConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	execer, ok := conn.(driver.ExecerContext)
+	if !ok {
+		conn.Close()
+
+		return nil, errors.New("could not set schema: driver connection does not support ExecContext")
+	}
+
+	if _, err := execer.ExecContext(ctx, "SET search_path TO "+c.schema, nil); err != nil {
+		conn.Close()
+
+		return nil, fmt.Errorf("could not set schema to %s: %w", c.schema, err)
+	}
+
+	return conn, nil
+}
+
+type dsnConnector struct {
+	driver driver.Driver
+	dsn    string
+}
+
+func (c dsnConnector) Connect(_ context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
@@ -0,0 +1,181 @@
+package dbutils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const snapshotMasked = "<masked>"
+
+// UpdateSnapshots rewrites the snapshot files of MatchSnapshot, default is the TEST_UPDATE_SNAPSHOTS env.
+//   - Set it in TestMain to bind it to a flag of the test package, like -update.
+//     The package does not register the flag itself, it would clash with the flags of the test binary.
+var UpdateSnapshots, _ = strconv.ParseBool(os.Getenv("TEST_UPDATE_SNAPSHOTS"))
+
+type snapshotColumn struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type snapshot struct {
+	Columns []snapshotColumn `json:"columns"`
+	Rows    [][]any          `json:"rows"`
+}
+
+// MatchSnapshot compares the query result with the snapshot file "testdata/snapshots/<name>.json".
+//   - Set UpdateSnapshots or run tests with TEST_UPDATE_SNAPSHOTS=true to create or rewrite it.
+//   - Use WithArgs for the query arguments and WithMask for volatile columns.
+//     Arguments are an option instead of variadic args, so they can be combined with the other options.
+func (db *DatabaseTest) MatchSnapshot(t *testing.T, name, query string, opts ...OptionAssert) bool {
+	t.Helper()
+
+	opt := apply(opts)
+
+	result, err := db.db.query(opt.Ctx, query, opt.Args...)
+	if err != nil {
+		t.Errorf("could not match snapshot %s: %v", name, err)
+
+		return false
+	}
+
+	actual, err := newSnapshot(result, opt.Mask).marshal()
+	if err != nil {
+		t.Errorf("could not encode snapshot %s: %v", name, err)
+
+		return false
+	}
+
+	file := filepath.Join(opt.SnapshotDir, name+".json")
+
+	if !UpdateSnapshots {
+		expected, err := os.ReadFile(file)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				t.Errorf("snapshot %s not found, run with TEST_UPDATE_SNAPSHOTS=true to create it", file)
+
+				return false
+			}
+
+			t.Errorf("could not read snapshot %s: %v", file, err)
+
+			return false
+		}
+
+		if !bytes.Equal(expected, actual) {
+			t.Errorf("snapshot %s mismatch, run with TEST_UPDATE_SNAPSHOTS=true to rewrite it\n%s", file, diffLines(string(expected), string(actual)))
+
+			return false
+		}
+
+		return true
+	}
+
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		t.Errorf("could not create snapshot folder: %v", err)
+
+		return false
+	}
+
+	if err := os.WriteFile(file, actual, 0o644); err != nil {
+		t.Errorf("could not write snapshot %s: %v", file, err)
+
+		return false
+	}
+
+	t.Logf("snapshot %s written", file)
+
+	return true
+}
+
+func newSnapshot(result *queryResult, mask []string) *snapshot {
+	s := &snapshot{
+		Columns: make([]snapshotColumn, 0, len(result.Columns)),
+		Rows:    make([][]any, 0, len(result.Rows)),
+	}
+
+	for i, column := range result.Columns {
+		s.Columns = append(s.Columns, snapshotColumn{Name: column, Type: result.Types[i]})
+	}
+
+	for _, row := range result.Rows {
+		values := make([]any, 0, len(row))
+		for i, v := range row {
+			if slices.Contains(mask, result.Columns[i]) && v != nil {
+				values = append(values, snapshotMasked)
+
+				continue
+			}
+
+			values = append(values, snapshotValue(v))
+		}
+
+		s.Rows = append(s.Rows, values)
+	}
+
+	return s
+}
+
+// snapshotValue converts the value to a stable JSON value.
+func snapshotValue(v any) any {
+	switch v := v.(type) {
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	case []byte:
+		return string(v)
+	default:
+		if _, err := json.Marshal(v); err != nil {
+			return fmt.Sprint(v)
+		}
+
+		return v
+	}
+}
+
+func (s *snapshot) marshal() ([]byte, error) {
+	var buf bytes.Buffer
+
+	encoder := json.NewEncoder(&buf)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+
+	if err := encoder.Encode(s); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// diffLines shows the differing lines of the expected and actual content.
+func diffLines(expected, actual string) string {
+	e := strings.Split(expected, "\n")
+	a := strings.Split(actual, "\n")
+
+	var diff []string
+	for i := range max(len(e), len(a)) {
+		var le, la string
+		if i < len(e) {
+			le = e[i]
+		}
+
+		if i < len(a) {
+			la = a[i]
+		}
+
+		if le == la {
+			continue
+		}
+
+		diff = append(diff, fmt.Sprintf("  line %d:\n    - %s\n    + %s", i+1, le, la))
+	}
+
+	return strings.Join(diff, "\n")
+}
@@ -0,0 +1,398 @@
+package dbutils
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// Transaction is a database handle running all work inside one outer transaction.
+// Transactions started on the handle are mapped to savepoints, closing it rolls back everything.
+type Transaction struct {
+	*Database
+
+	conn *sql.Conn
+	tx   *sql.Tx
+}
+
+type TransactionTest struct {
+	*DatabaseTest
+
+	sql *sql.DB
+}
+
+func (tx *TransactionTest) Sql() *sql.DB {
+	return tx.sql
+}
+
+// IsolatedTx returns a handle which work is rolled back in t.Cleanup.
+//   - Code using db.BeginTx keeps working, transactions are mapped to savepoints.
+//   - Each statement outside a transaction runs in a savepoint, so a failed statement doesn't abort the others.
+//   - The handle has one connection, concurrent transactions run one after the other.
+//     Using the handle inside an open transaction of it blocks, use the transaction.
+func (db *DatabaseTest) IsolatedTx(t *testing.T, opts ...OptionContext) *TransactionTest {
+	t.Helper()
+
+	tx, err := db.db.isolatedTx(t, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		if err := tx.Close(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	return &TransactionTest{
+		DatabaseTest: &DatabaseTest{db: tx.Database},
+		sql:          tx.DB,
+	}
+}
+
+// IsolatedTx returns a handle running all work inside one outer transaction.
+//   - Close the handle to roll back the work.
+func (db *Database) IsolatedTx(opts ...OptionContext) (*Transaction, error) {
+	return db.isolatedTx(nil, opts...)
+}
+
+func (db *Database) isolatedTx(t *testing.T, opts ...OptionContext) (*Transaction, error) {
+	opt := apply(opts)
+
+	if t != nil {
+		t.Helper()
+		t.Log("begin isolated transaction")
+	}
+
+	conn, err := db.DB.Conn(opt.Ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not get connection: %w", err)
+	}
+
+	// not bound to the context, it would roll back the transaction when canceled
+	tx, err := conn.BeginTx(context.Background(), nil)
+	if err != nil {
+		conn.Close()
+
+		return nil, fmt.Errorf("could not begin transaction: %w", err)
+	}
+
+	// savepoints are a stack, concurrent transactions wait for the connection instead of interleaving
+	txDB := sql.OpenDB(&txConnector{tx: tx})
+	txDB.SetMaxOpenConns(1)
+
+	return &Transaction{
+		Database: New(txDB, WithDSN(db.dsn)),
+		conn:     conn,
+		tx:       tx,
+	}, nil
+}
+
+// Close rolls back the outer transaction and releases its connection.
+func (tx *Transaction) Close() error {
+	var errs []error
+	if err := tx.DB.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("could not close transaction handle: %w", err))
+	}
+
+	if err := tx.tx.Rollback(); err != nil {
+		errs = append(errs, fmt.Errorf("could not rollback transaction: %w", err))
+	}
+
+	if err := tx.conn.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("could not close connection: %w", err))
+	}
+
+	return errors.Join(errs...)
+}
+
+// ///////////////////////////////////////////////////////////////////////////
+
+// txConnector opens connections sharing the outer transaction.
+type txConnector struct {
+	tx *sql.Tx
+
+	// mu serializes the statements on the transaction
+	mu        sync.Mutex
+	savepoint int
+}
+
+func (c *txConnector) Connect(context.Context) (driver.Conn, error) {
+	return &txConn{connector: c}, nil
+}
+
+func (c *txConnector) Driver() driver.Driver {
+	return txDriver{}
+}
+
+func (c *txConnector) nextSavepoint() string {
+	c.savepoint++
+
+	return "sp_" + strconv.Itoa(c.savepoint)
+}
+
+type txDriver struct{}
+
+func (txDriver) Open(string) (driver.Conn, error) {
+	return nil, errors.New("transaction driver opens connections only with its connector")
+}
+
+type txConn struct {
+	connector *txConnector
+
+	// savepoint of the transaction started on this connection
+	savepoint string
+}
+
+// run executes fn under the lock, statements outside a transaction are wrapped with a savepoint.
+func (cn *txConn) run(ctx context.Context, fn func() error) error {
+	cn.connector.mu.Lock()
+	defer cn.connector.mu.Unlock()
+
+	if cn.savepoint != "" {
+		return fn()
+	}
+
+	savepoint := cn.connector.nextSavepoint()
+	if _, err := cn.connector.tx.ExecContext(ctx, "SAVEPOINT "+savepoint); err != nil {
+		return err
+	}
+
+	if err := fn(); err != nil {
+		if _, errRollback := cn.connector.tx.ExecContext(context.Background(), "ROLLBACK TO SAVEPOINT "+savepoint+"; RELEASE SAVEPOINT "+savepoint); errRollback != nil {
+			return errors.Join(err, errRollback)
+		}
+
+		return err
+	}
+
+	_, err := cn.connector.tx.ExecContext(ctx, "RELEASE SAVEPOINT "+savepoint)
+
+	return err
+}
+
+func (cn *txConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	var result sql.Result
+	err := cn.run(ctx, func() error {
+		var err error
+		result, err = cn.connector.tx.ExecContext(ctx, query, namedArgs(args)...)
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (cn *txConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	var result *txRows
+	err := cn.run(ctx, func() error {
+		rows, err := cn.connector.tx.QueryContext(ctx, query, namedArgs(args)...)
+		if err != nil {
+			return err
+		}
+
+		// rows are buffered, the connection can't run other statements while rows are open
+		result, err = bufferRows(rows)
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (cn *txConn) BeginTx(ctx context.Context, _ driver.TxOptions) (driver.Tx, error) {
+	cn.connector.mu.Lock()
+	defer cn.connector.mu.Unlock()
+
+	savepoint := cn.connector.nextSavepoint()
+	if _, err := cn.connector.tx.ExecContext(ctx, "SAVEPOINT "+savepoint); err != nil {
+		return nil, err
+	}
+
+	cn.savepoint = savepoint
+
+	return &txSavepoint{conn: cn}, nil
+}
+
+func (cn *txConn) Begin() (driver.Tx, error) {
+	return cn.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (cn *txConn) Prepare(query string) (driver.Stmt, error) {
+	return &txStmt{conn: cn, query: query}, nil
+}
+
+func (cn *txConn) Close() error {
+	return nil
+}
+
+// CheckNamedValue passes all arguments to the underlying driver as is.
+func (cn *txConn) CheckNamedValue(*driver.NamedValue) error {
+	return nil
+}
+
+type txSavepoint struct {
+	conn *txConn
+}
+
+func (s *txSavepoint) Commit() error {
+	return s.end("RELEASE SAVEPOINT ")
+}
+
+func (s *txSavepoint) Rollback() error {
+	return s.end("ROLLBACK TO SAVEPOINT ", "RELEASE SAVEPOINT ")
+}
+
+func (s *txSavepoint) end(commands ...string) error {
+	connector := s.conn.connector
+
+	connector.mu.Lock()
+	defer connector.mu.Unlock()
+
+	savepoint := s.conn.savepoint
+	s.conn.savepoint = ""
+
+	for _, command := range commands {
+		if _, err := connector.tx.Exec(command + savepoint); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type txStmt struct {
+	conn  *txConn
+	query string
+}
+
+func (s *txStmt) Close() error {
+	return nil
+}
+
+func (s *txStmt) NumInput() int {
+	return -1
+}
+
+func (s *txStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return s.conn.ExecContext(ctx, s.query, args)
+}
+
+func (s *txStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	return s.conn.QueryContext(ctx, s.query, args)
+}
+
+func (s *txStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.conn.ExecContext(context.Background(), s.query, valuesToNamed(args))
+}
+
+func (s *txStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.conn.QueryContext(context.Background(), s.query, valuesToNamed(args))
+}
+
+// txRows are rows read completely from the transaction.
+type txRows struct {
+	columns []string
+	types   []string
+	rows    [][]driver.Value
+	index   int
+}
+
+func bufferRows(rows *sql.Rows) (*txRows, error) {
+	defer rows.Close()
+
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &txRows{
+		columns: make([]string, 0, len(columnTypes)),
+		types:   make([]string, 0, len(columnTypes)),
+	}
+
+	for _, columnType := range columnTypes {
+		result.columns = append(result.columns, columnType.Name())
+		result.types = append(result.types, columnType.DatabaseTypeName())
+	}
+
+	for rows.Next() {
+		values := make([]any, len(columnTypes))
+		pointers := make([]any, len(columnTypes))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+
+		row := make([]driver.Value, len(values))
+		for i, v := range values {
+			row[i] = v
+		}
+
+		result.rows = append(result.rows, row)
+	}
+
+	return result, rows.Err()
+}
+
+func (r *txRows) Columns() []string {
+	return r.columns
+}
+
+func (r *txRows) ColumnTypeDatabaseTypeName(index int) string {
+	return r.types[index]
+}
+
+func (r *txRows) Close() error {
+	return nil
+}
+
+func (r *txRows) Next(dest []driver.Value) error {
+	if r.index >= len(r.rows) {
+		return io.EOF
+	}
+
+	copy(dest, r.rows[r.index])
+	r.index++
+
+	return nil
+}
+
+func namedArgs(args []driver.NamedValue) []any {
+	values := make([]any, 0, len(args))
+	for _, arg := range args {
+		if arg.Name != "" {
+			values = append(values, sql.Named(arg.Name, arg.Value))
+
+			continue
+		}
+
+		values = append(values, arg.Value)
+	}
+
+	return values
+}
+
+func valuesToNamed(args []driver.Value) []driver.NamedValue {
+	named := make([]driver.NamedValue, 0, len(args))
+	for i, arg := range args {
+		named = append(named, driver.NamedValue{Ordinal: i + 1, Value: arg})
+	}
+
+	return named
+}
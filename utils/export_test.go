@@ -0,0 +1,4 @@
+package utils
+
+// ReapWith exposes reap to the tests, with a fake docker client.
+var ReapWith = reap
@@ -0,0 +1,346 @@
+package kafkautils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// ErrNotMarked is returned by the consume helpers on a topic without a mark.
+var ErrNotMarked = errors.New("topic is not marked, call Mark before producing the messages")
+
+// Mark is the position of the consume helpers, the end offsets of the topics when it is taken.
+//   - Helpers of a mark read only the messages produced after it, other marks don't move it.
+//   - Helpers on a topic not in the mark return ErrNotMarked, marking at the call would skip
+//     the messages already produced by the code under test.
+//   - Each helper moves the mark after the messages it read.
+type Mark struct {
+	kafka *Kafka
+
+	mu      sync.Mutex
+	offsets map[string]map[int32]int64
+}
+
+// Mark returns a mark at the end offsets of the topics, consume helpers of it read only the later messages.
+//   - Take it before running the code under test, so messages produced earlier by other tests are skipped.
+func (k *Kafka) Mark(ctx context.Context, topics ...string) (*Mark, error) {
+	m := &Mark{
+		kafka:   k,
+		offsets: make(map[string]map[int32]int64, len(topics)),
+	}
+
+	if err := m.mark(ctx, topics...); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// Mark sets the mark of the test at the end offsets of the topics, consume helpers read only the later messages.
+//   - Call it before running the code under test, so messages produced earlier by other tests are skipped.
+//   - Each test has its own mark, tests running in parallel don't move each other's marks.
+//   - Consume helpers fail on a topic without a mark of the test.
+func (k *KafkaTest) Mark(t *testing.T, topics ...string) {
+	t.Helper()
+
+	if err := k.testMark(t).mark(t.Context(), topics...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Consume returns the next n messages of the topic after the test's mark, fails if they don't arrive in the timeout.
+func (k *KafkaTest) Consume(t *testing.T, topic string, n int, timeout time.Duration) []*kgo.Record {
+	t.Helper()
+
+	records, err := k.testMark(t).Consume(t.Context(), topic, n, timeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return records
+}
+
+// Consume returns the next n messages of the topic.
+//   - Returns the messages read so far with an error if they don't arrive in the timeout.
+func (m *Mark) Consume(ctx context.Context, topic string, n int, timeout time.Duration) ([]*kgo.Record, error) {
+	records := make([]*kgo.Record, 0, n)
+	if n <= 0 {
+		return records, nil
+	}
+
+	err := m.consume(ctx, topic, timeout, func(r *kgo.Record) bool {
+		records = append(records, r)
+
+		return len(records) >= n
+	})
+	if err != nil {
+		return records, fmt.Errorf("failed to consume %d messages from %s, got %d: %w", n, topic, len(records), err)
+	}
+
+	return records, nil
+}
+
+// WaitFor returns the first message of the topic after the test's mark accepted by the matcher,
+// fails if none arrives in the timeout.
+//   - Messages not accepted by the matcher are skipped.
+func (k *KafkaTest) WaitFor(t *testing.T, topic string, matcher func(*kgo.Record) bool, timeout time.Duration) *kgo.Record {
+	t.Helper()
+
+	record, err := k.testMark(t).WaitFor(t.Context(), topic, matcher, timeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return record
+}
+
+// WaitFor returns the first message of the topic accepted by the matcher.
+func (m *Mark) WaitFor(ctx context.Context, topic string, matcher func(*kgo.Record) bool, timeout time.Duration) (*kgo.Record, error) {
+	var record *kgo.Record
+	err := m.consume(ctx, topic, timeout, func(r *kgo.Record) bool {
+		if matcher(r) {
+			record = r
+
+			return true
+		}
+
+		return false
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to wait for message on %s: %w", topic, err)
+	}
+
+	return record, nil
+}
+
+// WaitForJSON returns the first message of the topic decoded from JSON and accepted by the matcher.
+//   - Messages which can't be decoded to T are skipped.
+//   - Nil matcher accepts the first decoded message.
+func WaitForJSON[T any](t *testing.T, k *KafkaTest, topic string, matcher func(T) bool, timeout time.Duration) T {
+	t.Helper()
+
+	var value T
+	_, err := k.testMark(t).WaitFor(t.Context(), topic, func(r *kgo.Record) bool {
+		var v T
+		if err := json.Unmarshal(r.Value, &v); err != nil {
+			return false
+		}
+
+		if matcher != nil && !matcher(v) {
+			return false
+		}
+
+		value = v
+
+		return true
+	}, timeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return value
+}
+
+// AssertNoMessages checks that no message arrives on the topic after the test's mark within the duration.
+func (k *KafkaTest) AssertNoMessages(t *testing.T, topic string, within time.Duration) bool {
+	t.Helper()
+
+	var records []*kgo.Record
+	err := k.testMark(t).consume(t.Context(), topic, within, func(r *kgo.Record) bool {
+		records = append(records, r)
+
+		return false
+	})
+	if err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatal(err)
+	}
+
+	if len(records) > 0 {
+		t.Errorf("expected no messages on %s, got %d, first: key=%q value=%q", topic, len(records), records[0].Key, records[0].Value)
+
+		return false
+	}
+
+	return true
+}
+
+// ///////////////////////////////////////////////////////////////////
+
+// testMark returns the mark of the test, created on the first call.
+func (k *KafkaTest) testMark(t *testing.T) *Mark {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if m, ok := k.marks[t]; ok {
+		return m
+	}
+
+	if k.marks == nil {
+		k.marks = make(map[*testing.T]*Mark)
+	}
+
+	m := &Mark{
+		kafka:   k.Kafka,
+		offsets: map[string]map[int32]int64{},
+	}
+
+	k.marks[t] = m
+
+	t.Cleanup(func() {
+		k.mu.Lock()
+		defer k.mu.Unlock()
+
+		delete(k.marks, t)
+	})
+
+	return m
+}
+
+func (m *Mark) mark(ctx context.Context, topics ...string) error {
+	offsets, err := m.kafka.endOffsets(ctx, topics...)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for topic, partitions := range offsets {
+		m.offsets[topic] = partitions
+	}
+
+	return nil
+}
+
+// endOffsets returns the end offsets of the partitions, topics not created yet have no partitions.
+func (k *Kafka) endOffsets(ctx context.Context, topics ...string) (map[string]map[int32]int64, error) {
+	listed, err := k.Admin.ListEndOffsets(ctx, topics...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list end offsets: %w", err)
+	}
+
+	offsets := make(map[string]map[int32]int64, len(topics))
+	for _, topic := range topics {
+		offsets[topic] = map[int32]int64{}
+	}
+
+	var errs []error
+	listed.Each(func(o kadm.ListedOffset) {
+		if o.Err != nil {
+			if !errors.Is(o.Err, kerr.UnknownTopicOrPartition) {
+				errs = append(errs, fmt.Errorf("failed to list end offset of %s/%d: %w", o.Topic, o.Partition, o.Err))
+			}
+
+			return
+		}
+
+		offsets[o.Topic][o.Partition] = o.Offset
+	})
+
+	return offsets, errors.Join(errs...)
+}
+
+// consume reads the messages of the topic after the mark until fn returns true.
+//   - The mark is moved after the messages passed to fn.
+//   - Returns context.DeadlineExceeded if fn doesn't return true in the timeout.
+func (m *Mark) consume(ctx context.Context, topic string, timeout time.Duration, fn func(*kgo.Record) bool) error {
+	m.mu.Lock()
+	_, marked := m.offsets[topic]
+	m.mu.Unlock()
+
+	if !marked {
+		return fmt.Errorf("%w: %s", ErrNotMarked, topic)
+	}
+
+	// partitions added after the mark are read from the start
+	current, err := m.kafka.endOffsets(ctx, topic)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	offsets := make(map[int32]kgo.Offset, len(current[topic]))
+	for partition := range current[topic] {
+		offset, ok := m.offsets[topic][partition]
+		if !ok {
+			offsets[partition] = kgo.NewOffset().AtStart()
+
+			continue
+		}
+
+		offsets[partition] = kgo.NewOffset().At(offset)
+	}
+	m.mu.Unlock()
+
+	// options of the producer client keep the TLS and SASL settings, consume without its group
+	opts := append(slices.Clone(m.kafka.Client.Kafka.Opts()), kgo.ConsumerGroup(""))
+	if len(offsets) == 0 {
+		// topic is not created yet
+		opts = append(opts, kgo.ConsumeTopics(topic), kgo.ConsumeResetOffset(kgo.NewOffset().AtStart()))
+	} else {
+		opts = append(opts, kgo.ConsumePartitions(map[string]map[int32]kgo.Offset{topic: offsets}))
+	}
+
+	client, err := kgo.NewClient(opts...)
+	if err != nil {
+		return fmt.Errorf("failed to create consumer: %w", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for {
+		fetches := client.PollFetches(ctx)
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		var errs []error
+		fetches.EachError(func(t string, p int32, err error) {
+			errs = append(errs, fmt.Errorf("failed to fetch %s/%d: %w", t, p, err))
+		})
+
+		if err := errors.Join(errs...); err != nil {
+			return err
+		}
+
+		done := false
+		for iter := fetches.RecordIter(); !iter.Done() && !done; {
+			r := iter.Next()
+			if r.Topic != topic {
+				// topics consumed by the options of the producer client
+				continue
+			}
+
+			m.advance(r)
+
+			done = fn(r)
+		}
+
+		if done {
+			return nil
+		}
+	}
+}
+
+// advance moves the mark of the record's partition after the record.
+func (m *Mark) advance(r *kgo.Record) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.offsets[r.Topic] == nil {
+		m.offsets[r.Topic] = map[int32]int64{}
+	}
+
+	m.offsets[r.Topic][r.Partition] = r.Offset + 1
+}
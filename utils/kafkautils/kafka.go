@@ -3,10 +3,13 @@ package kafkautils
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sync"
 	"testing"
 
 	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kerr"
 	"github.com/twmb/franz-go/pkg/kgo"
 	"github.com/worldline-go/wkafka"
 )
@@ -19,15 +22,21 @@ type Kafka struct {
 
 type KafkaTest struct {
 	*Kafka
+
+	// marks of the consume helpers per test, removed in the test cleanup
+	mu    sync.Mutex
+	marks map[*testing.T]*Mark
 }
 
 type Topic struct {
-	Name string
+	Name string `yaml:"name"`
 
 	// Partitions defaults to -1, which means the broker default.
-	Partitions int32
+	Partitions int32 `yaml:"partitions"`
 	// ReplicationFactor defaults to -1, which means the broker default.
-	ReplicationFactor int16
+	ReplicationFactor int16 `yaml:"replicationFactor"`
+	// Configs are the topic configs like cleanup.policy, retention.ms or min.insync.replicas.
+	Configs map[string]string `yaml:"configs"`
 }
 
 type ModifiedPartitioner struct {
@@ -144,6 +153,8 @@ func (k *Kafka) deleteTopics(t *testing.T, ctx context.Context, topics ...string
 	return nil
 }
 
+// CreateTopics creates the topics, existing topics are updated with the configs of the topic.
+//   - Responses of existing topics have a nil Err.
 func (k *KafkaTest) CreateTopics(t *testing.T, topics ...Topic) []kadm.CreateTopicResponse {
 	responses, err := k.Kafka.createTopics(t, t.Context(), topics...)
 	if err != nil {
@@ -153,6 +164,8 @@ func (k *KafkaTest) CreateTopics(t *testing.T, topics ...Topic) []kadm.CreateTop
 	return responses
 }
 
+// CreateTopics creates the topics, existing topics are updated with the configs of the topic.
+//   - Responses of existing topics have a nil Err.
 func (k *Kafka) CreateTopics(ctx context.Context, topics ...Topic) ([]kadm.CreateTopicResponse, error) {
 	return k.createTopics(nil, ctx, topics...)
 }
@@ -174,9 +187,25 @@ func (k *Kafka) createTopics(t *testing.T, ctx context.Context, topics ...Topic)
 			replicationFactor = -1
 		}
 
-		response, err := k.Admin.CreateTopic(ctx, partitions, replicationFactor, nil, topic.Name)
+		var configs map[string]*string
+		if len(topic.Configs) > 0 {
+			configs = make(map[string]*string, len(topic.Configs))
+			for name, value := range topic.Configs {
+				configs[name] = &value
+			}
+		}
+
+		response, err := k.Admin.CreateTopic(ctx, partitions, replicationFactor, configs, topic.Name)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create topic %s: %w", topic.Name, err)
+			if !errors.Is(err, kerr.TopicAlreadyExists) {
+				return nil, fmt.Errorf("failed to create topic %s: %w", topic.Name, err)
+			}
+
+			if err := k.alterTopicConfigs(ctx, topic); err != nil {
+				return nil, err
+			}
+
+			response.Err = nil
 		}
 
 		responses = append(responses, response)
@@ -185,6 +214,31 @@ func (k *Kafka) createTopics(t *testing.T, ctx context.Context, topics ...Topic)
 	return responses, nil
 }
 
+// alterTopicConfigs sets the configs of an existing topic, other configs are kept.
+func (k *Kafka) alterTopicConfigs(ctx context.Context, topic Topic) error {
+	if len(topic.Configs) == 0 {
+		return nil
+	}
+
+	configs := make([]kadm.AlterConfig, 0, len(topic.Configs))
+	for name, value := range topic.Configs {
+		configs = append(configs, kadm.AlterConfig{Op: kadm.SetConfig, Name: name, Value: &value})
+	}
+
+	responses, err := k.Admin.AlterTopicConfigs(ctx, configs, topic.Name)
+	if err != nil {
+		return fmt.Errorf("failed to alter topic %s configs: %w", topic.Name, err)
+	}
+
+	for _, response := range responses {
+		if response.Err != nil {
+			return fmt.Errorf("failed to alter topic %s configs: %w %s", topic.Name, response.Err, response.ErrMessage)
+		}
+	}
+
+	return nil
+}
+
 // Publish publishes messages to the specified topic.
 //   - If the message is a byte slice, it will be sent as is.
 //   - If the message is any other type, it will be marshaled to JSON.
@@ -0,0 +1,82 @@
+package kafkautils
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LoadTopics reads topic definitions from a YAML file.
+//
+// File has a topics list, or is the list itself:
+//
+//	topics:
+//	  - name: payments
+//	    partitions: 3
+//	    replicationFactor: 1
+//	    configs:
+//	      cleanup.policy: compact
+//	      min.insync.replicas: "1"
+func LoadTopics(file string) ([]Topic, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read topics file %s: %w", file, err)
+	}
+
+	return parseTopics(file, data)
+}
+
+// LoadTopicsFS reads topic definitions from a YAML file of the fsys.
+func LoadTopicsFS(fsys fs.FS, file string) ([]Topic, error) {
+	data, err := fs.ReadFile(fsys, file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read topics file %s: %w", file, err)
+	}
+
+	return parseTopics(file, data)
+}
+
+// CreateTopicsFile creates the topics defined in the YAML file, see LoadTopics.
+func (k *KafkaTest) CreateTopicsFile(t *testing.T, file string) []Topic {
+	t.Helper()
+
+	topics, err := LoadTopics(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	k.CreateTopics(t, topics...)
+
+	return topics
+}
+
+func parseTopics(file string, data []byte) ([]Topic, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, fmt.Errorf("failed to parse topics file %s: %w", file, err)
+	}
+
+	if len(node.Content) == 0 {
+		return nil, nil
+	}
+
+	var definition struct {
+		Topics []Topic `yaml:"topics"`
+	}
+
+	var err error
+	if node.Content[0].Kind == yaml.SequenceNode {
+		err = node.Content[0].Decode(&definition.Topics)
+	} else {
+		err = node.Content[0].Decode(&definition)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse topics file %s: %w", file, err)
+	}
+
+	return definition.Topics, nil
+}
@@ -0,0 +1,180 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/client"
+)
+
+// ReapOptions selects the resources removed by Reap.
+type ReapOptions struct {
+	// Labels are label selectors, "key" or "key=value", like the docker filter.
+	// Resources must have the LabelService label and all selectors, LabelEnv resources are skipped.
+	Labels []string
+	// OlderThan skips resources created in this duration, 0 selects all.
+	OlderThan time.Duration
+	// DryRun lists the resources without removing them.
+	DryRun bool
+}
+
+// Resource is a docker container, network or volume selected by Reap.
+type Resource struct {
+	ID      string
+	Name    string
+	Created time.Time
+	Labels  map[string]string
+}
+
+// Reaped is the result of Reap.
+type Reaped struct {
+	Containers []Resource
+	Networks   []Resource
+	Volumes    []Resource
+}
+
+// Reap removes the containers, networks and volumes of this library left by killed test runs.
+//   - Containers are selected by the labels, see Labels, and removed with their anonymous volumes.
+//   - Networks and volumes with the same labels are removed after the containers.
+//   - Result has only the removed resources, all selected ones with DryRun.
+//   - Errors of a resource don't stop the others, all of them are returned joined.
+func Reap(ctx context.Context, opts ReapOptions) (*Reaped, error) {
+	// testcontainers client panics without docker
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to docker: %w", err)
+	}
+	defer cli.Close()
+
+	if _, err := cli.Ping(ctx); err != nil {
+		return nil, fmt.Errorf("could not connect to docker: %w", err)
+	}
+
+	return reap(ctx, cli, opts)
+}
+
+// reapClient is the part of the docker client used by Reap.
+type reapClient interface {
+	ContainerList(ctx context.Context, options container.ListOptions) ([]container.Summary, error)
+	ContainerRemove(ctx context.Context, containerID string, options container.RemoveOptions) error
+	NetworkList(ctx context.Context, options network.ListOptions) ([]network.Summary, error)
+	NetworkRemove(ctx context.Context, networkID string) error
+	VolumeList(ctx context.Context, options volume.ListOptions) (volume.ListResponse, error)
+	VolumeRemove(ctx context.Context, volumeID string, force bool) error
+}
+
+func reap(ctx context.Context, cli reapClient, opts ReapOptions) (*Reaped, error) {
+	args := filters.NewArgs(filters.Arg("label", LabelService))
+	for _, selector := range opts.Labels {
+		args.Add("label", selector)
+	}
+
+	now := time.Now()
+	// testenv containers are kept until testenv down
+	old := func(created time.Time, labels map[string]string) bool {
+		_, env := labels[LabelEnv]
+
+		return !env && now.Sub(created) >= opts.OlderThan
+	}
+
+	selected := &Reaped{}
+
+	containers, err := cli.ContainerList(ctx, container.ListOptions{All: true, Filters: args})
+	if err != nil {
+		return nil, fmt.Errorf("could not list containers: %w", err)
+	}
+
+	for _, c := range containers {
+		created := time.Unix(c.Created, 0)
+		if !old(created, c.Labels) {
+			continue
+		}
+
+		name := c.ID[:min(12, len(c.ID))]
+		if len(c.Names) > 0 {
+			name = strings.TrimPrefix(c.Names[0], "/")
+		}
+
+		selected.Containers = append(selected.Containers, Resource{ID: c.ID, Name: name, Created: created, Labels: c.Labels})
+	}
+
+	networks, err := cli.NetworkList(ctx, network.ListOptions{Filters: args})
+	if err != nil {
+		return nil, fmt.Errorf("could not list networks: %w", err)
+	}
+
+	for _, n := range networks {
+		if !old(n.Created, n.Labels) {
+			continue
+		}
+
+		selected.Networks = append(selected.Networks, Resource{ID: n.ID, Name: n.Name, Created: n.Created, Labels: n.Labels})
+	}
+
+	volumes, err := cli.VolumeList(ctx, volume.ListOptions{Filters: args})
+	if err != nil {
+		return nil, fmt.Errorf("could not list volumes: %w", err)
+	}
+
+	var errs []error
+	for _, v := range volumes.Volumes {
+		created, err := time.Parse(time.RFC3339, v.CreatedAt)
+		if err != nil {
+			// age is unknown, the volume may be in use
+			errs = append(errs, fmt.Errorf("skipped volume %s, could not parse its creation time: %w", v.Name, err))
+
+			continue
+		}
+
+		if !old(created, v.Labels) {
+			continue
+		}
+
+		selected.Volumes = append(selected.Volumes, Resource{ID: v.Name, Name: v.Name, Created: created, Labels: v.Labels})
+	}
+
+	if opts.DryRun {
+		return selected, errors.Join(errs...)
+	}
+
+	removed := &Reaped{}
+	for _, c := range selected.Containers {
+		if err := cli.ContainerRemove(ctx, c.ID, container.RemoveOptions{Force: true, RemoveVolumes: true}); err != nil {
+			errs = append(errs, fmt.Errorf("could not remove container %s: %w", c.Name, err))
+
+			continue
+		}
+
+		removed.Containers = append(removed.Containers, c)
+	}
+
+	// networks are in use until their containers are removed
+	for _, n := range selected.Networks {
+		if err := cli.NetworkRemove(ctx, n.ID); err != nil {
+			errs = append(errs, fmt.Errorf("could not remove network %s: %w", n.Name, err))
+
+			continue
+		}
+
+		removed.Networks = append(removed.Networks, n)
+	}
+
+	for _, v := range selected.Volumes {
+		if err := cli.VolumeRemove(ctx, v.ID, true); err != nil {
+			errs = append(errs, fmt.Errorf("could not remove volume %s: %w", v.Name, err))
+
+			continue
+		}
+
+		removed.Volumes = append(removed.Volumes, v)
+	}
+
+	return removed, errors.Join(errs...)
+}
@@ -0,0 +1,159 @@
+package utils_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/stretchr/testify/require"
+
+	"github.com/worldline-go/test/utils"
+)
+
+// fakeDocker lists the resources matching the label filters like docker and records the removed ones.
+type fakeDocker struct {
+	containers []container.Summary
+	networks   []network.Summary
+	volumes    []*volume.Volume
+
+	// failing resource ids return an error on remove
+	failing map[string]bool
+	removed []string
+}
+
+func (f *fakeDocker) ContainerList(_ context.Context, options container.ListOptions) ([]container.Summary, error) {
+	var result []container.Summary
+	for _, c := range f.containers {
+		if options.Filters.MatchKVList("label", c.Labels) {
+			result = append(result, c)
+		}
+	}
+
+	return result, nil
+}
+
+func (f *fakeDocker) NetworkList(_ context.Context, options network.ListOptions) ([]network.Summary, error) {
+	var result []network.Summary
+	for _, n := range f.networks {
+		if options.Filters.MatchKVList("label", n.Labels) {
+			result = append(result, n)
+		}
+	}
+
+	return result, nil
+}
+
+func (f *fakeDocker) VolumeList(_ context.Context, options volume.ListOptions) (volume.ListResponse, error) {
+	var result volume.ListResponse
+	for _, v := range f.volumes {
+		if options.Filters.MatchKVList("label", v.Labels) {
+			result.Volumes = append(result.Volumes, v)
+		}
+	}
+
+	return result, nil
+}
+
+func (f *fakeDocker) ContainerRemove(_ context.Context, id string, _ container.RemoveOptions) error {
+	return f.remove(id)
+}
+
+func (f *fakeDocker) NetworkRemove(_ context.Context, id string) error {
+	return f.remove(id)
+}
+
+func (f *fakeDocker) VolumeRemove(_ context.Context, id string, _ bool) error {
+	return f.remove(id)
+}
+
+func (f *fakeDocker) remove(id string) error {
+	if f.failing[id] {
+		return errors.New("in use")
+	}
+
+	f.removed = append(f.removed, id)
+
+	return nil
+}
+
+func newFakeDocker() *fakeDocker {
+	now := time.Now()
+	old := now.Add(-2 * time.Hour)
+	labels := func(session string, extra ...string) map[string]string {
+		l := map[string]string{utils.LabelService: "postgres", utils.LabelSession: session}
+		for _, key := range extra {
+			l[key] = "true"
+		}
+
+		return l
+	}
+
+	return &fakeDocker{
+		containers: []container.Summary{
+			{ID: "c-old", Names: []string{"/c-old"}, Created: old.Unix(), Labels: labels("s1")},
+			{ID: "c-new", Names: []string{"/c-new"}, Created: now.Unix(), Labels: labels("s1")},
+			{ID: "c-other", Names: []string{"/c-other"}, Created: old.Unix(), Labels: labels("s2")},
+			{ID: "c-env", Names: []string{"/c-env"}, Created: old.Unix(), Labels: labels("s1", utils.LabelEnv)},
+			{ID: "c-foreign", Names: []string{"/c-foreign"}, Created: old.Unix(), Labels: map[string]string{"app": "x"}},
+		},
+		networks: []network.Summary{
+			{ID: "n-old", Name: "n-old", Created: old, Labels: labels("s1")},
+		},
+		volumes: []*volume.Volume{
+			{Name: "v-old", CreatedAt: old.Format(time.RFC3339), Labels: labels("s1")},
+			{Name: "v-invalid", CreatedAt: "yesterday", Labels: labels("s1")},
+		},
+		failing: map[string]bool{},
+	}
+}
+
+func names(resources []utils.Resource) []string {
+	result := make([]string, 0, len(resources))
+	for _, r := range resources {
+		result = append(result, r.Name)
+	}
+
+	return result
+}
+
+func TestReapSelect(t *testing.T) {
+	docker := newFakeDocker()
+
+	reaped, err := utils.ReapWith(t.Context(), docker, utils.ReapOptions{
+		Labels:    []string{utils.LabelSession + "=s1"},
+		OlderThan: time.Hour,
+		DryRun:    true,
+	})
+	require.ErrorContains(t, err, "v-invalid")
+
+	// new, other session, testenv and foreign resources are not selected
+	require.Equal(t, []string{"c-old"}, names(reaped.Containers))
+	require.Equal(t, []string{"n-old"}, names(reaped.Networks))
+	require.Equal(t, []string{"v-old"}, names(reaped.Volumes))
+	require.Empty(t, docker.removed)
+
+	// without age limit the new container is selected too
+	reaped, _ = utils.ReapWith(t.Context(), docker, utils.ReapOptions{
+		Labels: []string{utils.LabelSession + "=s1"},
+		DryRun: true,
+	})
+	require.Equal(t, []string{"c-old", "c-new"}, names(reaped.Containers))
+}
+
+func TestReapRemoved(t *testing.T) {
+	docker := newFakeDocker()
+	docker.failing["c-old"] = true
+
+	reaped, err := utils.ReapWith(t.Context(), docker, utils.ReapOptions{OlderThan: time.Hour})
+	require.ErrorContains(t, err, "could not remove container c-old")
+
+	// failed resources are not reported as removed
+	require.Equal(t, []string{"c-other"}, names(reaped.Containers))
+	require.Equal(t, []string{"n-old"}, names(reaped.Networks))
+	require.Equal(t, []string{"v-old"}, names(reaped.Volumes))
+	require.Equal(t, []string{"c-other", "n-old", "v-old"}, docker.removed)
+}
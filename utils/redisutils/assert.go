@@ -0,0 +1,123 @@
+package redisutils
+
+import (
+	"errors"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// AssertKey checks the string value of the key.
+func (r *RedisTest) AssertKey(t *testing.T, key string, expected string) bool {
+	t.Helper()
+
+	value, err := r.Client.Get(t.Context(), key).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			t.Errorf("key %s does not exist, expected %q", key, expected)
+
+			return false
+		}
+
+		t.Fatalf("failed to get %s: %v", key, err)
+	}
+
+	if value != expected {
+		t.Errorf("key %s: expected %q, got %q", key, expected, value)
+
+		return false
+	}
+
+	return true
+}
+
+// AssertTTL checks the remaining time to live of the key is in delta of the expected.
+//   - Expected -1 checks the key has no expiration.
+func (r *RedisTest) AssertTTL(t *testing.T, key string, expected, delta time.Duration) bool {
+	t.Helper()
+
+	ttl, err := r.Client.PTTL(t.Context(), key).Result()
+	if err != nil {
+		t.Fatalf("failed to get ttl of %s: %v", key, err)
+	}
+
+	// go-redis returns the negative replies as is, not in milliseconds
+	switch ttl {
+	case -2:
+		t.Errorf("key %s does not exist", key)
+
+		return false
+	case -1:
+		if expected != -1 {
+			t.Errorf("key %s has no expiration, expected ttl %s", key, expected)
+
+			return false
+		}
+
+		return true
+	}
+
+	if expected == -1 {
+		t.Errorf("key %s: expected no expiration, got ttl %s", key, ttl)
+
+		return false
+	}
+
+	if diff := expected - ttl; diff < -delta || diff > delta {
+		t.Errorf("key %s: expected ttl %s ± %s, got %s", key, expected, delta, ttl)
+
+		return false
+	}
+
+	return true
+}
+
+// AssertHash checks the fields of the hash are equal to the expected.
+func (r *RedisTest) AssertHash(t *testing.T, key string, expected map[string]string) bool {
+	t.Helper()
+
+	value, err := r.Client.HGetAll(t.Context(), key).Result()
+	if err != nil {
+		t.Fatalf("failed to get hash %s: %v", key, err)
+	}
+
+	if maps.Equal(value, expected) {
+		return true
+	}
+
+	if len(value) == 0 {
+		t.Errorf("hash %s does not exist", key)
+
+		return false
+	}
+
+	fields := slices.Sorted(maps.Keys(expected))
+	for field := range value {
+		if _, ok := expected[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+
+	var diff strings.Builder
+	for _, field := range fields {
+		want, wantOk := expected[field]
+		got, gotOk := value[field]
+
+		switch {
+		case !gotOk:
+			diff.WriteString("\n  - " + field + ": " + want)
+		case !wantOk:
+			diff.WriteString("\n  + " + field + ": " + got)
+		case want != got:
+			diff.WriteString("\n  ~ " + field + ": expected " + want + ", got " + got)
+		}
+	}
+
+	t.Errorf("hash %s mismatch:%s", key, diff.String())
+
+	return false
+}
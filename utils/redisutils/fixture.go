@@ -0,0 +1,148 @@
+package redisutils
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gopkg.in/yaml.v3"
+)
+
+// fixture is a key of a fixture file.
+//   - Only one of Value, Hash, List and Set is used.
+type fixture struct {
+	Value any               `yaml:"value"`
+	Hash  map[string]string `yaml:"hash"`
+	List  []string          `yaml:"list"`
+	Set   []string          `yaml:"set"`
+	TTL   time.Duration     `yaml:"ttl"`
+}
+
+// LoadFixtures sets the keys of the YAML fixture files.
+//
+// A scalar is a string value, a mapping sets the type and the ttl of the key.
+// Existing keys are replaced.
+//
+//	greeting: hello
+//	session:1:
+//	  value: {"user": 1}
+//	  ttl: 1m
+//	user:1:
+//	  hash:
+//	    name: alice
+//	queue:
+//	  list: [a, b]
+//	tags:
+//	  set: [x, y]
+func (r *RedisTest) LoadFixtures(t *testing.T, files ...string) {
+	t.Helper()
+
+	if err := r.Redis.LoadFixtures(t.Context(), files...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// LoadFixtures sets the keys of the YAML fixture files.
+func (r *Redis) LoadFixtures(ctx context.Context, files ...string) error {
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read fixture file %s: %w", file, err)
+		}
+
+		fixtures, err := parseFixtures(data)
+		if err != nil {
+			return fmt.Errorf("failed to parse fixture file %s: %w", file, err)
+		}
+
+		if err := r.setFixtures(ctx, fixtures); err != nil {
+			return fmt.Errorf("failed to load fixture file %s: %w", file, err)
+		}
+	}
+
+	return nil
+}
+
+func parseFixtures(data []byte) (map[string]fixture, error) {
+	var nodes map[string]yaml.Node
+	if err := yaml.Unmarshal(data, &nodes); err != nil {
+		return nil, err
+	}
+
+	fixtures := make(map[string]fixture, len(nodes))
+	for key, node := range nodes {
+		var f fixture
+		if node.Kind == yaml.ScalarNode {
+			f.Value = node.Value
+		} else if err := node.Decode(&f); err != nil {
+			return nil, fmt.Errorf("key %s: %w", key, err)
+		}
+
+		fixtures[key] = f
+	}
+
+	return fixtures, nil
+}
+
+func (r *Redis) setFixtures(ctx context.Context, fixtures map[string]fixture) error {
+	keys := make([]string, 0, len(fixtures))
+	for key := range fixtures {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		f := fixtures[key]
+
+		var value any
+		if f.Value != nil {
+			v, err := encodeValue(f.Value)
+			if err != nil {
+				return fmt.Errorf("failed to encode value of %s: %w", key, err)
+			}
+
+			value = v
+		}
+
+		// keys can be on different slots, pipeline per key
+		_, err := r.Client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Del(ctx, key)
+
+			switch {
+			case f.Hash != nil:
+				pipe.HSet(ctx, key, f.Hash)
+			case f.List != nil:
+				pipe.RPush(ctx, key, toAny(f.List)...)
+			case f.Set != nil:
+				pipe.SAdd(ctx, key, toAny(f.Set)...)
+			default:
+				pipe.Set(ctx, key, value, 0)
+			}
+
+			if f.TTL > 0 {
+				pipe.Expire(ctx, key, f.TTL)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to set %s: %w", key, err)
+		}
+	}
+
+	return nil
+}
+
+func toAny(values []string) []any {
+	result := make([]any, 0, len(values))
+	for _, v := range values {
+		result = append(result, v)
+	}
+
+	return result
+}
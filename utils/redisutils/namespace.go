@@ -0,0 +1,367 @@
+package redisutils
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// Namespace is a Redis handle with its own client prefixing all keys.
+//   - Prefix is a cluster hash tag, all keys of the namespace are on the same slot and multi-key commands work.
+//   - Commands with keys in unusual places (like SORT ... STORE) are sent as is.
+//   - KEYS and SCAN replies are filtered to the namespace and returned without the prefix.
+type Namespace struct {
+	*Redis
+
+	Prefix string
+}
+
+type NamespaceTest struct {
+	*RedisTest
+
+	Prefix string
+}
+
+// Namespace returns a handle which keys are prefixed with a unique per-test prefix.
+//   - Keys of the namespace are deleted in t.Cleanup.
+func (r *RedisTest) Namespace(t *testing.T) *NamespaceTest {
+	t.Helper()
+
+	ns, err := r.Redis.Namespace(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		// test context is canceled at cleanup
+		if err := ns.Close(context.Background()); err != nil {
+			t.Error(err)
+		}
+	})
+
+	return &NamespaceTest{
+		RedisTest: &RedisTest{Redis: ns.Redis},
+		Prefix:    ns.Prefix,
+	}
+}
+
+// Namespace returns a handle which keys are prefixed with a unique prefix.
+//   - Close the namespace to delete its keys.
+func (r *Redis) Namespace(ctx context.Context) (*Namespace, error) {
+	if r.parent != nil {
+		return nil, fmt.Errorf("could not create namespace in namespace %s", r.prefix)
+	}
+
+	commands, err := r.keySpecs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := "{" + r.NameGen("ns") + "}:"
+
+	client := redis.NewUniversalClient(r.opts)
+	client.AddHook(namespaceHook{prefix: prefix, commands: commands})
+
+	return &Namespace{
+		Redis: &Redis{
+			Client: client,
+			opts:   r.opts,
+			prefix: prefix,
+			parent: r,
+		},
+		Prefix: prefix,
+	}, nil
+}
+
+var (
+	// processID is random, pids are reused across hosts and containers sharing a server
+	processID = strings.ReplaceAll(uuid.NewString(), "-", "")[:12]
+	// nameCounter is shared by the handles of the process, they may use the same server
+	nameCounter atomic.Int64
+)
+
+// NameGen returns a unique name with the prefix, unique across handles and processes using the same server.
+func (r *Redis) NameGen(prefix string) string {
+	return prefix + "_" + processID + "_" + strconv.FormatInt(nameCounter.Add(1), 10)
+}
+
+// Close deletes the keys of the namespace and closes its client.
+func (n *Namespace) Close(ctx context.Context) error {
+	if err := n.FlushAll(ctx); err != nil {
+		return fmt.Errorf("could not delete keys of namespace %s: %w", n.Prefix, err)
+	}
+
+	if err := n.Client.Close(); err != nil {
+		return fmt.Errorf("could not close namespace %s client: %w", n.Prefix, err)
+	}
+
+	return nil
+}
+
+// deleteKeys deletes the keys matching the pattern with SCAN, on every master of a cluster.
+func (r *Redis) deleteKeys(ctx context.Context, pattern string) error {
+	deleteFn := func(ctx context.Context, client *redis.Client) error {
+		iter := client.Scan(ctx, 0, pattern, 1000).Iterator()
+
+		var keys []string
+		for iter.Next(ctx) {
+			keys = append(keys, iter.Val())
+		}
+
+		if err := iter.Err(); err != nil {
+			return err
+		}
+
+		for chunk := range slices.Chunk(keys, 1000) {
+			// keys of other namespaces may be on other slots, delete one by one
+			if _, err := client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+				for _, key := range chunk {
+					pipe.Unlink(ctx, key)
+				}
+
+				return nil
+			}); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	switch client := r.Client.(type) {
+	case *redis.ClusterClient:
+		return client.ForEachMaster(ctx, deleteFn)
+	case *redis.Client:
+		return deleteFn(ctx, client)
+	default:
+		return fmt.Errorf("unsupported client type %T", r.Client)
+	}
+}
+
+// ///////////////////////////////////////////////////////////////////
+
+// keySpec is the position of the keys in a command.
+type keySpec struct {
+	First int
+	// Last is negative when it is counted from the end.
+	Last int
+	Step int
+}
+
+// numKeysPos is the position of numkeys of the commands with a variable number of keys.
+var numKeysPos = map[string]int{
+	"eval":        2,
+	"evalsha":     2,
+	"eval_ro":     2,
+	"evalsha_ro":  2,
+	"fcall":       2,
+	"fcall_ro":    2,
+	"zunion":      1,
+	"zinter":      1,
+	"zdiff":       1,
+	"zintercard":  1,
+	"sintercard":  1,
+	"lmpop":       1,
+	"zmpop":       1,
+	"blmpop":      2,
+	"bzmpop":      2,
+	"zunionstore": 2,
+	"zinterstore": 2,
+	"zdiffstore":  2,
+}
+
+// keySpecs returns the key positions of the commands reported by the server, loaded once.
+func (r *Redis) keySpecs(ctx context.Context) (map[string]keySpec, error) {
+	r.commandsOnce.Do(func() {
+		infos, err := r.Client.Command(ctx).Result()
+		if err != nil {
+			r.commandsErr = fmt.Errorf("failed to get command info: %w", err)
+
+			return
+		}
+
+		r.commands = make(map[string]keySpec, len(infos))
+		for _, info := range infos {
+			if info.FirstKeyPos <= 0 {
+				continue
+			}
+
+			r.commands[strings.ToLower(info.Name)] = keySpec{
+				First: int(info.FirstKeyPos),
+				Last:  int(info.LastKeyPos),
+				Step:  max(int(info.StepCount), 1),
+			}
+		}
+	})
+
+	return r.commands, r.commandsErr
+}
+
+type namespaceHook struct {
+	prefix   string
+	commands map[string]keySpec
+}
+
+func (h namespaceHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h namespaceHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		h.prefixKeys(cmd)
+
+		err := next(ctx, cmd)
+
+		h.trimReply(cmd)
+
+		return err
+	}
+}
+
+func (h namespaceHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		for _, cmd := range cmds {
+			h.prefixKeys(cmd)
+		}
+
+		err := next(ctx, cmds)
+
+		for _, cmd := range cmds {
+			h.trimReply(cmd)
+		}
+
+		return err
+	}
+}
+
+// prefixKeys adds the prefix to the key arguments of the command in place.
+func (h namespaceHook) prefixKeys(cmd redis.Cmder) {
+	args := cmd.Args()
+	name := cmd.Name()
+
+	switch name {
+	case "keys":
+		h.prefixArg(args, 1)
+
+		return
+	case "scan":
+		for i := 2; i < len(args)-1; i++ {
+			if s, ok := args[i].(string); ok && strings.EqualFold(s, "match") {
+				h.prefixArg(args, i+1)
+			}
+		}
+
+		return
+	case "xread", "xreadgroup":
+		for i := 1; i < len(args); i++ {
+			if s, ok := args[i].(string); ok && strings.EqualFold(s, "streams") {
+				// keys are followed by the same number of ids
+				streams := args[i+1:]
+				for j := range len(streams) / 2 {
+					h.prefixArg(streams, j)
+				}
+
+				return
+			}
+		}
+
+		return
+	}
+
+	if pos, ok := numKeysPos[name]; ok {
+		if name == "zunionstore" || name == "zinterstore" || name == "zdiffstore" {
+			// destination
+			h.prefixArg(args, 1)
+		}
+
+		if pos >= len(args) {
+			return
+		}
+
+		n, err := strconv.Atoi(fmt.Sprint(args[pos]))
+		if err != nil {
+			return
+		}
+
+		for i := pos + 1; i <= pos+n && i < len(args); i++ {
+			h.prefixArg(args, i)
+		}
+
+		return
+	}
+
+	spec, ok := h.commands[name]
+	if !ok {
+		return
+	}
+
+	last := spec.Last
+	if last < 0 {
+		last = len(args) + last
+	}
+
+	for i := spec.First; i <= last && i < len(args); i += spec.Step {
+		h.prefixArg(args, i)
+	}
+}
+
+func (h namespaceHook) prefixArg(args []any, i int) {
+	if i < 0 || i >= len(args) {
+		return
+	}
+
+	switch v := args[i].(type) {
+	case string:
+		args[i] = h.prefix + v
+	case []byte:
+		args[i] = append([]byte(h.prefix), v...)
+	}
+}
+
+// trimReply removes the prefix from the keys in the reply.
+func (h namespaceHook) trimReply(cmd redis.Cmder) {
+	if cmd.Err() != nil {
+		return
+	}
+
+	switch c := cmd.(type) {
+	case *redis.ScanCmd:
+		keys, cursor := c.Val()
+		c.SetVal(h.trimKeys(keys), cursor)
+	case *redis.StringSliceCmd:
+		switch cmd.Name() {
+		case "keys":
+			c.SetVal(h.trimKeys(c.Val()))
+		case "blpop", "brpop":
+			if v := c.Val(); len(v) > 0 {
+				v[0] = strings.TrimPrefix(v[0], h.prefix)
+			}
+		}
+	case *redis.KeyValuesCmd:
+		key, values := c.Val()
+		c.SetVal(strings.TrimPrefix(key, h.prefix), values)
+	case *redis.ZWithKeyCmd:
+		if v := c.Val(); v != nil {
+			v.Key = strings.TrimPrefix(v.Key, h.prefix)
+		}
+	}
+}
+
+// trimKeys returns the keys of the namespace without the prefix.
+func (h namespaceHook) trimKeys(keys []string) []string {
+	result := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if v, ok := strings.CutPrefix(key, h.prefix); ok {
+			result = append(result, v)
+		}
+	}
+
+	return result
+}
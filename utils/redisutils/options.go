@@ -0,0 +1,24 @@
+package redisutils
+
+import (
+	"github.com/redis/go-redis/v9"
+)
+
+type option struct {
+	ClientOpts []func(*redis.UniversalOptions)
+}
+
+func (o *option) apply(opts ...Option) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+type Option func(*option)
+
+// WithClientOptions changes the options of the client before it is created.
+func WithClientOptions(fn ...func(*redis.UniversalOptions)) Option {
+	return func(o *option) {
+		o.ClientOpts = append(o.ClientOpts, fn...)
+	}
+}
@@ -0,0 +1,177 @@
+package redisutils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type Redis struct {
+	Client redis.UniversalClient
+
+	opts *redis.UniversalOptions
+
+	// namespace of the client, keys are prefixed by its hook
+	prefix string
+	parent *Redis
+
+	commands     map[string]keySpec
+	commandsOnce sync.Once
+	commandsErr  error
+}
+
+type RedisTest struct {
+	*Redis
+}
+
+// New returns a Redis with a client connected to the addresses.
+//   - Multiple addresses create a cluster client.
+func New(ctx context.Context, addrs []string, opts ...Option) (*Redis, error) {
+	o := option{}
+	o.apply(opts...)
+
+	clientOpts := &redis.UniversalOptions{
+		Addrs: addrs,
+	}
+
+	for _, fn := range o.ClientOpts {
+		fn(clientOpts)
+	}
+
+	client := redis.NewUniversalClient(clientOpts)
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+
+		return nil, fmt.Errorf("failed to ping redis: %w", err)
+	}
+
+	return &Redis{
+		Client: client,
+		opts:   clientOpts,
+	}, nil
+}
+
+func NewTest(t *testing.T, addrs []string, opts ...Option) *RedisTest {
+	t.Helper()
+
+	r, err := New(t.Context(), addrs, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &RedisTest{
+		Redis: r,
+	}
+}
+
+// ///////////////////////////////////////////////////////////////////
+
+// FlushAll deletes all keys of all databases, on every master of a cluster.
+func (r *RedisTest) FlushAll(t *testing.T) {
+	t.Helper()
+
+	if err := r.Redis.FlushAll(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// FlushAll deletes all keys of all databases, on every master of a cluster.
+//   - On a namespace, only the keys of the namespace are deleted.
+func (r *Redis) FlushAll(ctx context.Context) error {
+	if r.parent != nil {
+		return r.parent.deleteKeys(ctx, r.prefix+"*")
+	}
+
+	var err error
+	if cluster, ok := r.Client.(*redis.ClusterClient); ok {
+		err = cluster.ForEachMaster(ctx, func(ctx context.Context, client *redis.Client) error {
+			return client.FlushAll(ctx).Err()
+		})
+	} else {
+		err = r.Client.FlushAll(ctx).Err()
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to flush all: %w", err)
+	}
+
+	return nil
+}
+
+// Set sets the value of the key, zero ttl means no expiration.
+//   - If the value is a string, byte slice or number, it will be sent as is.
+//   - If the value is any other type, it will be marshaled to JSON.
+func (r *RedisTest) Set(t *testing.T, key string, value any, ttl time.Duration) {
+	t.Helper()
+
+	if err := r.Redis.Set(t.Context(), key, value, ttl); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Set sets the value of the key, zero ttl means no expiration.
+//   - If the value is a string, byte slice or number, it will be sent as is.
+//   - If the value is any other type, it will be marshaled to JSON.
+func (r *Redis) Set(ctx context.Context, key string, value any, ttl time.Duration) error {
+	v, err := encodeValue(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode value of %s: %w", key, err)
+	}
+
+	if err := r.Client.Set(ctx, key, v, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to set %s: %w", key, err)
+	}
+
+	return nil
+}
+
+// Get returns the value of the key, fails if the key doesn't exist.
+func (r *RedisTest) Get(t *testing.T, key string) string {
+	t.Helper()
+
+	value, err := r.Redis.Get(t.Context(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return value
+}
+
+// Get returns the value of the key.
+func (r *Redis) Get(ctx context.Context, key string) (string, error) {
+	value, err := r.Client.Get(ctx, key).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return "", fmt.Errorf("key %s does not exist", key)
+		}
+
+		return "", fmt.Errorf("failed to get %s: %w", key, err)
+	}
+
+	return value, nil
+}
+
+// GetJSON decodes the JSON value of the key to v.
+func (r *RedisTest) GetJSON(t *testing.T, key string, v any) {
+	t.Helper()
+
+	value := r.Get(t, key)
+	if err := json.Unmarshal([]byte(value), v); err != nil {
+		t.Fatalf("failed to decode value of %s: %v", key, err)
+	}
+}
+
+func encodeValue(value any) (any, error) {
+	switch v := value.(type) {
+	case string, []byte, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64, bool:
+		return v, nil
+	default:
+		return json.Marshal(v)
+	}
+}
@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"fmt"
+	"hash/fnv"
+	"os"
+	"strconv"
+
+	"github.com/testcontainers/testcontainers-go"
+)
+
+const (
+	// LabelService is the label of the service name on the containers.
+	LabelService = "test.service"
+	// LabelSession is the label of the test session id on the containers.
+	LabelSession = "test.session"
+	// LabelShared is set on the containers shared by the packages of a session.
+	LabelShared = "test.shared"
+	// LabelEnv is set on the long-running containers of the testenv command.
+	LabelEnv = "test.env"
+)
+
+// Labels returns the labels of a service container, EnvToLabels with the service and session labels.
+func Labels(service string) map[string]string {
+	labels := EnvToLabels()
+	labels[LabelService] = service
+	labels[LabelSession] = SessionID()
+
+	if Shared() {
+		labels[LabelShared] = "true"
+	}
+
+	return labels
+}
+
+// Shared reports if the containers are shared by the test packages, enabled with TEST_SHARED=true env.
+//   - Packages of a session attach to one container per service and options, it is not terminated in Stop.
+//   - Containers are removed by the testcontainers reaper when the session ends.
+//     With TESTCONTAINERS_RYUK_DISABLED they are kept, remove them with testreaper -session.
+func Shared() bool {
+	v, _ := strconv.ParseBool(os.Getenv("TEST_SHARED"))
+
+	return v
+}
+
+// SessionID returns the TEST_SESSION_ID env, default is the testcontainers session id.
+// Testcontainers session is the same for all packages of a "go test ./..." run.
+func SessionID() string {
+	if v := os.Getenv("TEST_SESSION_ID"); v != "" {
+		return v
+	}
+
+	return testcontainers.SessionID()
+}
+
+// SharedName returns the container name of a shared service, keyed by the session, image and options.
+func SharedName(service, image string, options ...any) string {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%s\x00%s", SessionID(), image)
+
+	for _, option := range options {
+		fmt.Fprintf(h, "\x00%v", option)
+	}
+
+	return "test-" + service + "-" + strconv.FormatUint(h.Sum64(), 36)
+}
@@ -0,0 +1,47 @@
+package utils_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/worldline-go/test/utils"
+)
+
+func TestLabels(t *testing.T) {
+	t.Setenv("TEST_SESSION_ID", "session-1")
+	t.Setenv("TEST_LABEL_CI_PROJECT_ID", "42")
+	t.Setenv("TEST_SHARED", "")
+
+	labels := utils.Labels("postgres")
+	require.Equal(t, "42", labels["ci.project.id"])
+	require.Equal(t, "postgres", labels[utils.LabelService])
+	require.Equal(t, "session-1", labels[utils.LabelSession])
+	require.NotContains(t, labels, utils.LabelShared)
+
+	t.Setenv("TEST_SHARED", "true")
+	require.Equal(t, "true", utils.Labels("postgres")[utils.LabelShared])
+}
+
+func TestShared(t *testing.T) {
+	for value, want := range map[string]bool{"": false, "false": false, "invalid": false, "true": true, "1": true} {
+		t.Setenv("TEST_SHARED", value)
+		require.Equal(t, want, utils.Shared(), "TEST_SHARED=%q", value)
+	}
+}
+
+func TestSharedName(t *testing.T) {
+	t.Setenv("TEST_SESSION_ID", "session-1")
+
+	name := utils.SharedName("redis", "redis:7", "redis", 1)
+	require.Regexp(t, `^test-redis-[0-9a-z]+$`, name)
+	require.Equal(t, name, utils.SharedName("redis", "redis:7", "redis", 1))
+
+	require.NotEqual(t, name, utils.SharedName("redis", "redis:8", "redis", 1))
+	require.NotEqual(t, name, utils.SharedName("redis", "redis:7", "redis", 2))
+	// option boundaries are part of the key
+	require.NotEqual(t, utils.SharedName("redis", "redis:7", "ab", "c"), utils.SharedName("redis", "redis:7", "a", "bc"))
+
+	t.Setenv("TEST_SESSION_ID", "session-2")
+	require.NotEqual(t, name, utils.SharedName("redis", "redis:7", "redis", 1))
+}
@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/docker/go-connections/nat"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+// StarterScript is the path of the script copied into the container after it starts.
+const StarterScript = "/usr/sbin/testcontainers_start.sh"
+
+// StarterEntrypoint waits for the starter script and runs it.
+// Use it for services which need to know their mapped host ports, known only after start.
+//   - An empty script is not run, StarterHook empties it before every start.
+func StarterEntrypoint() []string {
+	return []string{
+		"sh",
+		"-c",
+		"while [ ! -s " + StarterScript + " ]; do sleep 0.1; done; exec " + StarterScript,
+	}
+}
+
+// StarterHook copies the script returned by fn into the container after start.
+//   - Script of the previous start is emptied before a reused container is started again,
+//     mapped ports change and the stale script must not run. Emptied from the host,
+//     the container user may not be allowed to remove it.
+func StarterHook(fn func(ctx context.Context, c testcontainers.Container) (string, error)) testcontainers.ContainerLifecycleHooks {
+	return testcontainers.ContainerLifecycleHooks{
+		PreStarts: []testcontainers.ContainerHook{
+			func(ctx context.Context, c testcontainers.Container) error {
+				if err := c.CopyToContainer(ctx, nil, StarterScript, 0o755); err != nil {
+					return fmt.Errorf("could not empty starter script: %w", err)
+				}
+
+				return nil
+			},
+		},
+		PostStarts: []testcontainers.ContainerHook{
+			func(ctx context.Context, c testcontainers.Container) error {
+				script, err := fn(ctx, c)
+				if err != nil {
+					return err
+				}
+
+				if err := c.CopyToContainer(ctx, []byte(script), StarterScript, 0o755); err != nil {
+					return fmt.Errorf("could not copy starter script: %w", err)
+				}
+
+				return nil
+			},
+		},
+	}
+}
+
+// MappedAddress returns the host address of the container port.
+func MappedAddress(ctx context.Context, c testcontainers.Container, port nat.Port) (string, error) {
+	host, err := c.Host(ctx)
+	if err != nil {
+		return "", fmt.Errorf("could not get host: %w", err)
+	}
+
+	mapped, err := c.MappedPort(ctx, port)
+	if err != nil {
+		return "", fmt.Errorf("could not get mapped port %s: %w", port, err)
+	}
+
+	return net.JoinHostPort(host, mapped.Port()), nil
+}